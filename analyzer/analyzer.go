@@ -0,0 +1,139 @@
+package analyzer
+
+import "github.com/anukuljoshi/monkey/ast"
+
+// a name introduced by a let statement
+type binding struct {
+	name string
+	used bool
+}
+
+// scope mirrors the environments the evaluator creates: one for the
+// program, one per function call and one per for loop. Blocks of if, while
+// and switch share the scope around them.
+type scope struct {
+	parent   *scope
+	bindings map[string]*binding
+	// function bodies are analysed once the enclosing scope is complete,
+	// since a closure sees bindings made after it was created
+	functions []*ast.FunctionLiteral
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, bindings: map[string]*binding{}}
+}
+
+func (s *scope) use(name string) {
+	for current := s; current != nil; current = current.parent {
+		if b, ok := current.bindings[name]; ok {
+			b.used = true
+			return
+		}
+	}
+}
+
+type analyzer struct {
+	lets []*binding
+	// bindings made up front for top level lets of function literals,
+	// which the evaluator hoists
+	hoisted map[*ast.LetStatement]*binding
+}
+
+// UnusedBindings returns the names bound by let statements that are never
+// referenced afterwards, in the order they were bound. A name rebound in the
+// same scope is reported once for each let that goes unread.
+func UnusedBindings(program *ast.Program) []string {
+	a := &analyzer{hoisted: map[*ast.LetStatement]*binding{}}
+	s := newScope(nil)
+	a.hoist(program, s)
+	a.analyze(program, s)
+	a.analyzeFunctions(s)
+
+	unused := []string{}
+	for _, b := range a.lets {
+		if !b.used {
+			unused = append(unused, b.name)
+		}
+	}
+	return unused
+}
+
+func (a *analyzer) hoist(program *ast.Program, s *scope) {
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+		if _, ok := let.Value.(*ast.FunctionLiteral); ok {
+			b := &binding{name: let.Name.Value}
+			s.bindings[b.name] = b
+			a.hoisted[let] = b
+		}
+	}
+}
+
+func (a *analyzer) analyzeFunctions(s *scope) {
+	for _, fn := range s.functions {
+		fnScope := newScope(s)
+		if fn.Name != nil {
+			fnScope.bindings[fn.Name.Value] = &binding{name: fn.Name.Value, used: true}
+		}
+		for _, param := range fn.Parameters {
+			// parameters shadow outer names but are never reported
+			fnScope.bindings[param.Value] = &binding{name: param.Value, used: true}
+		}
+		a.analyze(fn.Body, fnScope)
+		a.analyzeFunctions(fnScope)
+	}
+}
+
+func (a *analyzer) analyze(node ast.Node, s *scope) {
+	if node == nil {
+		return
+	}
+	ast.Walk(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			if n.Value != nil {
+				a.analyze(n.Value, s)
+			}
+			b, ok := a.hoisted[n]
+			if !ok {
+				b = &binding{name: n.Name.Value}
+			}
+			s.bindings[b.name] = b
+			a.lets = append(a.lets, b)
+			return false
+		case *ast.AssignExpression:
+			// writing to a name is not a use of it
+			a.analyze(n.Value, s)
+			return false
+		case *ast.FunctionLiteral:
+			s.functions = append(s.functions, n)
+			return false
+		case *ast.TryExpression:
+			a.analyze(n.Body, s)
+			handlerScope := newScope(s)
+			// the caught error is never reported, like a parameter
+			handlerScope.bindings[n.Param.Value] = &binding{name: n.Param.Value, used: true}
+			a.analyze(n.Handler, handlerScope)
+			a.analyzeFunctions(handlerScope)
+			return false
+		case *ast.ForExpression:
+			loopScope := newScope(s)
+			if n.Init != nil {
+				a.analyze(n.Init, loopScope)
+			}
+			for _, child := range []ast.Node{n.Condition, n.Post, n.Body} {
+				if child != nil {
+					a.analyze(child, loopScope)
+				}
+			}
+			a.analyzeFunctions(loopScope)
+			return false
+		case *ast.Identifier:
+			s.use(n.Value)
+		}
+		return true
+	})
+}
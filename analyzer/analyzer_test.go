@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/anukuljoshi/monkey/lexer"
+	"github.com/anukuljoshi/monkey/parser"
+)
+
+func TestUnusedBindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"used", `let x = 5; x + 1;`, []string{}},
+		{"unused", `let x = 5; let y = 10; y;`, []string{"x"}},
+		{"used by later let", `let a = 1; let b = a * 2; b;`, []string{}},
+		{"rebound before use", `let x = 1; let x = 2; x;`, []string{"x"}},
+		{"rebound from itself", `let x = 1; let x = x + 1; x;`, []string{}},
+		{
+			"shadowed by parameter",
+			`let x = 1; let f = fn(x) { x }; f(2);`,
+			[]string{"x"},
+		},
+		{
+			"shadowed inside function",
+			`let x = 1; let f = fn() { let x = 2; x }; f();`,
+			[]string{"x"},
+		},
+		{
+			"unused inside function",
+			`let f = fn() { let tmp = 2; 3 }; f();`,
+			[]string{"tmp"},
+		},
+		{
+			"closure capture",
+			`let n = 3; let adder = fn(x) { fn(y) { x + y + n } }; adder(1)(2);`,
+			[]string{},
+		},
+		{
+			"recursion",
+			`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5);`,
+			[]string{},
+		},
+		{
+			"named function refers to itself",
+			`let f = 1; let g = fn f(n) { f(n - 1) }; g(1);`,
+			[]string{"f"},
+		},
+		{
+			"called before hoisted let",
+			`let x = double(4); let double = fn(n) { n * 2 }; x;`,
+			[]string{},
+		},
+		{
+			"value used before let is not hoisted",
+			`let x = later; let later = 5; x;`,
+			[]string{"later"},
+		},
+		{
+			"caught error shadows outer binding",
+			`let e = 1; try { f() } catch (e) { e }; let unused = 2;`,
+			[]string{"e", "unused"},
+		},
+		{
+			"unused inside catch",
+			`try { f() } catch (e) { let tmp = 1; 2 };`,
+			[]string{"tmp"},
+		},
+		{
+			"index assignment reads the container",
+			`let a = [1]; a[0] = 2;`,
+			[]string{},
+		},
+		{
+			"closure refers to later binding",
+			`let f = fn() { g() }; let g = fn() { 1 }; f();`,
+			[]string{},
+		},
+		{"assignment is not a use", `let x = 1; x = 2;`, []string{"x"}},
+		{"used in if block", `let x = 1; if (true) { let y = x; y }`, []string{}},
+		{
+			"for loop scope",
+			`let total = 0; for (let i = 0; i < 3; i++) { let unused = i; total = total + i; } total;`,
+			[]string{"unused"},
+		},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%s: parser has errors: %v", tt.name, p.Errors())
+		}
+
+		got := UnusedBindings(program)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected=%v, got=%v", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"strings"
 
 	"github.com/anukuljoshi/monkey/token"
@@ -37,10 +38,19 @@ func (p *Program) TokenLiteral() string {
 func (p *Program) String() string {
 	var out bytes.Buffer
 
-	for _, s := range p.Statements {
+	writeStatements(&out, p.Statements)
+	return out.String()
+}
+
+// writeStatements writes stmts in order, separating an expression statement
+// from the next statement with "; " so the output parses back the same way
+func writeStatements(out *bytes.Buffer, stmts []Statement) {
+	for i, s := range stmts {
 		out.WriteString(s.String())
+		if _, ok := s.(*ExpressionStatement); ok && i < len(stmts)-1 {
+			out.WriteString("; ")
+		}
 	}
-	return out.String()
 }
 
 // let statement
@@ -95,15 +105,29 @@ func (rs *ReturnStatement) TokenLiteral() string {
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(rs.TokenLiteral() + " ")
+	out.WriteString(rs.TokenLiteral())
 	if rs.ReturnValue != nil {
-		out.WriteString(rs.ReturnValue.String())
+		out.WriteString(" " + rs.ReturnValue.String())
 	}
 	out.WriteString(";")
 
 	return out.String()
 }
 
+// import statement
+type ImportStatement struct {
+	Token token.Token // token.IMPORT
+	Path  *StringLiteral
+}
+
+func (is *ImportStatement) statementNode() {}
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *ImportStatement) String() string {
+	return is.TokenLiteral() + " " + is.Path.String() + ";"
+}
+
 // expression statement
 type ExpressionStatement struct {
 	Token      token.Token // first token of the expression
@@ -135,6 +159,34 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+// big integer literal, for literals that do not fit in int64
+type BigIntegerLiteral struct {
+	Token token.Token // token.INT token
+	Value *big.Int
+}
+
+func (bl *BigIntegerLiteral) expressionNode() {}
+func (bl *BigIntegerLiteral) TokenLiteral() string {
+	return bl.Token.Literal
+}
+func (bl *BigIntegerLiteral) String() string {
+	return bl.Token.Literal
+}
+
+// float literal
+type FloatLiteral struct {
+	Token token.Token // token.FLOAT token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
 // prefix expression
 type PrefixExpression struct {
 	Token    token.Token // the prefix token : !, -
@@ -150,6 +202,9 @@ func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(pe.Operator)
+	if pe.Token.Type == token.NOT {
+		out.WriteString(" ")
+	}
 	out.WriteString(pe.Right.String())
 	out.WriteString(")")
 	return out.String()
@@ -177,6 +232,94 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// chained comparison, a < b <= c compares each operand with the next one
+// and holds when every comparison does. Operators[i] sits between
+// Operands[i] and Operands[i+1].
+type ChainedComparison struct {
+	Token     token.Token // the first operator token
+	Operands  []Expression
+	Operators []string
+}
+
+func (cc *ChainedComparison) expressionNode() {}
+func (cc *ChainedComparison) TokenLiteral() string {
+	return cc.Token.Literal
+}
+func (cc *ChainedComparison) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	for i, operand := range cc.Operands {
+		if i > 0 {
+			out.WriteString(" " + cc.Operators[i-1] + " ")
+		}
+		out.WriteString(operand.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+// postfix expression
+type PostfixExpression struct {
+	Token    token.Token // the postfix token: ++, --
+	Left     *Identifier
+	Operator string
+}
+
+func (pe *PostfixExpression) expressionNode() {}
+func (pe *PostfixExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+	return out.String()
+}
+
+// assign expression
+type AssignExpression struct {
+	Token token.Token // the = token
+	Name  *Identifier
+	Value Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ae.Name.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+// index assignment, stores into an array element or a hash entry in place
+type IndexAssignExpression struct {
+	Token token.Token // the = token
+	Left  *IndexExpression
+	Value Expression
+}
+
+func (ia *IndexAssignExpression) expressionNode() {}
+func (ia *IndexAssignExpression) TokenLiteral() string {
+	return ia.Token.Literal
+}
+func (ia *IndexAssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ia.Left.String())
+	out.WriteString(" = ")
+	out.WriteString(ia.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 // boolean literal
 type Boolean struct {
 	Token token.Token
@@ -196,6 +339,8 @@ type IfExpression struct {
 	Token       token.Token // if token
 	Condition   Expression
 	Consequence *BlockStatement
+	// for else if the alternative is a block holding just the nested if,
+	// with the nested if token as its token
 	Alternative *BlockStatement
 }
 
@@ -206,17 +351,201 @@ func (ie *IfExpression) TokenLiteral() string {
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("if")
+	out.WriteString("if (")
 	out.WriteString(ie.Condition.String())
-	out.WriteString(" ")
+	out.WriteString(") { ")
 	out.WriteString(ie.Consequence.String())
-	if ie.Alternative != nil {
-		out.WriteString("else ")
+	out.WriteString(" }")
+	if ie.Alternative != nil && ie.Alternative.Token.Type == token.IF {
+		out.WriteString(" else ")
+		out.WriteString(ie.Alternative.String())
+	} else if ie.Alternative != nil {
+		out.WriteString(" else { ")
 		out.WriteString(ie.Alternative.String())
+		out.WriteString(" }")
+	}
+	return out.String()
+}
+
+// switch expression
+type SwitchExpression struct {
+	Token   token.Token // switch token
+	Subject Expression
+	Cases   []*SwitchCase
+	Default *BlockStatement
+}
+
+// a single case arm of a switch expression
+type SwitchCase struct {
+	Token token.Token // case token
+	Value Expression
+	Body  *BlockStatement
+}
+
+func (sc *SwitchCase) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("case ")
+	out.WriteString(sc.Value.String())
+	out.WriteString(": ")
+	out.WriteString(sc.Body.String())
+	out.WriteString(";")
+	return out.String()
+}
+
+func (se *SwitchExpression) expressionNode() {}
+func (se *SwitchExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+func (se *SwitchExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("switch (")
+	out.WriteString(se.Subject.String())
+	out.WriteString(") { ")
+	for _, c := range se.Cases {
+		out.WriteString(c.String())
+		out.WriteString(" ")
+	}
+	if se.Default != nil {
+		out.WriteString("default: ")
+		out.WriteString(se.Default.String())
+		out.WriteString("; ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// while expression
+type WhileExpression struct {
+	Token     token.Token // while token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (we *WhileExpression) expressionNode() {}
+func (we *WhileExpression) TokenLiteral() string {
+	return we.Token.Literal
+}
+func (we *WhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while (")
+	out.WriteString(we.Condition.String())
+	out.WriteString(") { ")
+	out.WriteString(we.Body.String())
+	out.WriteString(" }")
+	return out.String()
+}
+
+// do-while expression, the body runs once before the condition is checked
+type DoWhileExpression struct {
+	Token     token.Token // do token
+	Body      *BlockStatement
+	Condition Expression
+}
+
+func (dw *DoWhileExpression) expressionNode() {}
+func (dw *DoWhileExpression) TokenLiteral() string {
+	return dw.Token.Literal
+}
+func (dw *DoWhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("do { ")
+	out.WriteString(dw.Body.String())
+	out.WriteString(" } while (")
+	out.WriteString(dw.Condition.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+// for expression, a C-style loop with optional init, condition and post
+type ForExpression struct {
+	Token     token.Token // for token
+	Init      Statement
+	Condition Expression
+	Post      Expression
+	Body      *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode() {}
+func (fe *ForExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fe.Init != nil {
+		out.WriteString(strings.TrimSuffix(fe.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fe.Condition != nil {
+		out.WriteString(fe.Condition.String())
+	}
+	out.WriteString("; ")
+	if fe.Post != nil {
+		out.WriteString(fe.Post.String())
 	}
+	out.WriteString(") { ")
+	out.WriteString(fe.Body.String())
+	out.WriteString(" }")
 	return out.String()
 }
 
+// try expression, when the body evaluates to an error the handler runs
+// instead with the error bound to Param
+type TryExpression struct {
+	Token   token.Token // try token
+	Body    *BlockStatement
+	Param   *Identifier
+	Handler *BlockStatement
+}
+
+func (te *TryExpression) expressionNode() {}
+func (te *TryExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try { ")
+	out.WriteString(te.Body.String())
+	out.WriteString(" } catch (")
+	out.WriteString(te.Param.String())
+	out.WriteString(") { ")
+	out.WriteString(te.Handler.String())
+	out.WriteString(" }")
+	return out.String()
+}
+
+// break statement
+type BreakStatement struct {
+	Token token.Token // break token
+}
+
+func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BreakStatement) String() string {
+	return bs.TokenLiteral() + ";"
+}
+
+// continue statement
+type ContinueStatement struct {
+	Token token.Token // continue token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+func (cs *ContinueStatement) String() string {
+	return cs.TokenLiteral() + ";"
+}
+
 // block statement
 type BlockStatement struct {
 	Token      token.Token // the { token
@@ -230,15 +559,14 @@ func (bs *BlockStatement) TokenLiteral() string {
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
-	for _, s := range bs.Statements {
-		out.WriteString(s.String())
-	}
+	writeStatements(&out, bs.Statements)
 	return out.String()
 }
 
 // function expressions
 type FunctionLiteral struct {
 	Token      token.Token // fn token
+	Name       *Identifier // nil for anonymous functions
 	Parameters []*Identifier
 	Body       *BlockStatement
 }
@@ -256,10 +584,15 @@ func (fl *FunctionLiteral) String() string {
 	}
 
 	out.WriteString(fl.TokenLiteral())
+	if fl.Name != nil {
+		out.WriteString(" ")
+		out.WriteString(fl.Name.String())
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(")")
+	out.WriteString(") { ")
 	out.WriteString(fl.Body.String())
+	out.WriteString(" }")
 
 	return out.String()
 }
@@ -293,7 +626,7 @@ func (ce *CallExpression) String() string {
 
 // string literal
 type StringLiteral struct {
-	Token token.Token // token.STRING token
+	Token token.Token // token.STRING or token.RAW_STRING token
 	Value string
 }
 
@@ -302,7 +635,33 @@ func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
 func (sl *StringLiteral) String() string {
-	return sl.Token.Literal
+	return quoteString(sl.Value)
+}
+
+// quoteString renders a string value as a literal, falling back to a raw
+// string when the value contains a double quote or text that would be
+// interpolated
+func quoteString(value string) string {
+	if strings.Contains(value, `"`) || strings.Contains(value, "${") {
+		return "`" + value + "`"
+	}
+	return `"` + value + `"`
+}
+
+// interpolated string, a string literal containing ${expr} segments
+type InterpolatedString struct {
+	Token token.Token // token.STRING token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *InterpolatedString) String() string {
+	// double quoted strings cannot contain a quote, so the literal is
+	// always safe to print back between quotes
+	return `"` + is.Token.Literal + `"`
 }
 
 // array literal
@@ -357,6 +716,30 @@ func (ie *IndexExpression) String() string {
 type HashLiteral struct {
 	Token token.Token // '{' token
 	Pairs map[Expression]Expression
+	Keys  []Expression // keys of Pairs in source order
+}
+
+// OrderedKeys returns the keys of Pairs in source order. Keys missing from
+// Keys, as in a literal built without the parser, come last in no fixed
+// order.
+func (hl *HashLiteral) OrderedKeys() []Expression {
+	if len(hl.Keys) == len(hl.Pairs) {
+		return hl.Keys
+	}
+	keys := make([]Expression, 0, len(hl.Pairs))
+	listed := make(map[Expression]bool, len(hl.Keys))
+	for _, key := range hl.Keys {
+		if _, ok := hl.Pairs[key]; ok && !listed[key] {
+			keys = append(keys, key)
+			listed[key] = true
+		}
+	}
+	for key := range hl.Pairs {
+		if !listed[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -367,8 +750,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	var pairs = []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+": ", value.String())
+	for _, key := range hl.OrderedKeys() {
+		pairs = append(pairs, key.String()+": "+hl.Pairs[key].String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -0,0 +1,127 @@
+package ast
+
+// Walk traverses the tree rooted at node in depth-first order, calling visit
+// for each node before its children. If visit returns false the children of
+// that node are skipped.
+func Walk(node Node, visit func(Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(n.Statements, visit)
+	case *BlockStatement:
+		walkStatements(n.Statements, visit)
+	case *LetStatement:
+		walkIdentifier(n.Name, visit)
+		walkExpression(n.Value, visit)
+	case *ReturnStatement:
+		walkExpression(n.ReturnValue, visit)
+	case *ImportStatement:
+		if n.Path != nil {
+			Walk(n.Path, visit)
+		}
+	case *ExpressionStatement:
+		walkExpression(n.Expression, visit)
+	case *PrefixExpression:
+		walkExpression(n.Right, visit)
+	case *InfixExpression:
+		walkExpression(n.Left, visit)
+		walkExpression(n.Right, visit)
+	case *ChainedComparison:
+		walkExpressions(n.Operands, visit)
+	case *PostfixExpression:
+		walkIdentifier(n.Left, visit)
+	case *AssignExpression:
+		walkIdentifier(n.Name, visit)
+		walkExpression(n.Value, visit)
+	case *IndexAssignExpression:
+		Walk(n.Left, visit)
+		walkExpression(n.Value, visit)
+	case *IfExpression:
+		walkExpression(n.Condition, visit)
+		walkBlock(n.Consequence, visit)
+		walkBlock(n.Alternative, visit)
+	case *SwitchExpression:
+		walkExpression(n.Subject, visit)
+		for _, c := range n.Cases {
+			walkExpression(c.Value, visit)
+			walkBlock(c.Body, visit)
+		}
+		walkBlock(n.Default, visit)
+	case *WhileExpression:
+		walkExpression(n.Condition, visit)
+		walkBlock(n.Body, visit)
+	case *DoWhileExpression:
+		walkBlock(n.Body, visit)
+		walkExpression(n.Condition, visit)
+	case *ForExpression:
+		if n.Init != nil {
+			Walk(n.Init, visit)
+		}
+		walkExpression(n.Condition, visit)
+		walkExpression(n.Post, visit)
+		walkBlock(n.Body, visit)
+	case *TryExpression:
+		walkBlock(n.Body, visit)
+		walkIdentifier(n.Param, visit)
+		walkBlock(n.Handler, visit)
+	case *FunctionLiteral:
+		if n.Name != nil {
+			walkIdentifier(n.Name, visit)
+		}
+		for _, p := range n.Parameters {
+			walkIdentifier(p, visit)
+		}
+		walkBlock(n.Body, visit)
+	case *CallExpression:
+		walkExpression(n.Function, visit)
+		walkExpressions(n.Arguments, visit)
+	case *InterpolatedString:
+		walkExpressions(n.Parts, visit)
+	case *ArrayLiteral:
+		walkExpressions(n.Elements, visit)
+	case *IndexExpression:
+		walkExpression(n.Left, visit)
+		walkExpression(n.Index, visit)
+	case *HashLiteral:
+		for _, key := range n.OrderedKeys() {
+			walkExpression(key, visit)
+			walkExpression(n.Pairs[key], visit)
+		}
+	}
+}
+
+func walkStatements(stmts []Statement, visit func(Node) bool) {
+	for _, s := range stmts {
+		if s != nil {
+			Walk(s, visit)
+		}
+	}
+}
+
+func walkExpressions(exps []Expression, visit func(Node) bool) {
+	for _, e := range exps {
+		walkExpression(e, visit)
+	}
+}
+
+func walkExpression(exp Expression, visit func(Node) bool) {
+	if exp != nil {
+		Walk(exp, visit)
+	}
+}
+
+// typed nil pointers would otherwise reach visit as non-nil Nodes
+func walkIdentifier(ident *Identifier, visit func(Node) bool) {
+	if ident != nil {
+		Walk(ident, visit)
+	}
+}
+
+func walkBlock(block *BlockStatement, visit func(Node) bool) {
+	if block != nil {
+		Walk(block, visit)
+	}
+}
@@ -0,0 +1,126 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anukuljoshi/monkey/ast"
+	"github.com/anukuljoshi/monkey/lexer"
+	"github.com/anukuljoshi/monkey/parser"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+	return program
+}
+
+func countNodes(node ast.Node, visit func(ast.Node) bool) map[string]int {
+	counts := map[string]int{}
+	ast.Walk(node, func(n ast.Node) bool {
+		counts[fmt.Sprintf("%T", n)]++
+		if visit != nil {
+			return visit(n)
+		}
+		return true
+	})
+	return counts
+}
+
+func TestWalk(t *testing.T) {
+	input := `
+let add = fn(a, b) { return a + b; };
+let xs = [1, 2, add(3, 4)];
+let h = {"one": 1, "two": -2};
+if (xs[0] < 2) { "small" } else { h["two"] };
+`
+	counts := countNodes(parseProgram(t, input), nil)
+
+	expected := map[string]int{
+		"*ast.Program":             1,
+		"*ast.LetStatement":        3,
+		"*ast.ExpressionStatement": 3,
+		"*ast.ReturnStatement":     1,
+		"*ast.BlockStatement":      3,
+		"*ast.FunctionLiteral":     1,
+		"*ast.CallExpression":      1,
+		"*ast.ArrayLiteral":        1,
+		"*ast.HashLiteral":         1,
+		"*ast.IfExpression":        1,
+		"*ast.IndexExpression":     2,
+		"*ast.InfixExpression":     2,
+		"*ast.PrefixExpression":    1,
+		"*ast.IntegerLiteral":      8,
+		"*ast.StringLiteral":       4,
+		// add, xs, h, a, b (params), a, b (body), add, xs, h
+		"*ast.Identifier": 10,
+	}
+
+	for typ, want := range expected {
+		if counts[typ] != want {
+			t.Errorf("count of %s wrong. expected=%d, got=%d", typ, want, counts[typ])
+		}
+	}
+	for typ := range counts {
+		if _, ok := expected[typ]; !ok {
+			t.Errorf("unexpected node type visited: %s", typ)
+		}
+	}
+}
+
+func TestWalkLoopsAndSwitch(t *testing.T) {
+	input := `
+let i = 0;
+for (let j = 0; j < 3; j++) { i = i + j; }
+while (i > 0) { i--; }
+do { break; } while (true);
+switch (i) { case 1: "one"; default: "other"; }
+`
+	counts := countNodes(parseProgram(t, input), nil)
+
+	expected := map[string]int{
+		"*ast.ForExpression":     1,
+		"*ast.WhileExpression":   1,
+		"*ast.DoWhileExpression": 1,
+		"*ast.SwitchExpression":  1,
+		"*ast.PostfixExpression": 2,
+		"*ast.AssignExpression":  1,
+		"*ast.BreakStatement":    1,
+		"*ast.LetStatement":      2,
+		"*ast.BlockStatement":    5,
+		"*ast.StringLiteral":     2,
+		"*ast.Boolean":           1,
+	}
+
+	for typ, want := range expected {
+		if counts[typ] != want {
+			t.Errorf("count of %s wrong. expected=%d, got=%d", typ, want, counts[typ])
+		}
+	}
+}
+
+func TestWalkPrune(t *testing.T) {
+	input := `let f = fn(x) { x * 2 }; f(1 + 2);`
+
+	counts := countNodes(parseProgram(t, input), func(n ast.Node) bool {
+		_, isFunction := n.(*ast.FunctionLiteral)
+		return !isFunction
+	})
+
+	if counts["*ast.FunctionLiteral"] != 1 {
+		t.Errorf("function literal should be visited once, got=%d", counts["*ast.FunctionLiteral"])
+	}
+	if counts["*ast.BlockStatement"] != 0 {
+		t.Errorf("function body should be pruned, got %d blocks", counts["*ast.BlockStatement"])
+	}
+	// only the call's 1 + 2 survives pruning
+	if counts["*ast.InfixExpression"] != 1 {
+		t.Errorf("expected 1 infix expression, got=%d", counts["*ast.InfixExpression"])
+	}
+	if counts["*ast.IntegerLiteral"] != 2 {
+		t.Errorf("expected 2 integer literals, got=%d", counts["*ast.IntegerLiteral"])
+	}
+}
@@ -0,0 +1,1104 @@
+package evaluator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+var builtins = map[string]*object.Builtin{
+	"len": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.Integer{
+					Value: int64(len(arg.Value)),
+				}
+			case *object.Array:
+				return &object.Integer{
+					Value: int64(len(arg.Elements)),
+				}
+			case *object.Hash:
+				return &object.Integer{
+					Value: int64(len(arg.Pairs)),
+				}
+			default:
+				return newError(
+					"argument to `len` not supported, got=%s",
+					args[0].Type(),
+				)
+			}
+		},
+	},
+	"first": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `first` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[0]
+			}
+			return NULL
+		},
+	},
+	"last": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `last` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[length-1]
+			}
+			return NULL
+		},
+	},
+	"rest": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `rest` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length > 0 {
+				newElements := make([]object.Object, length-1, length-1)
+				copy(newElements, arr.Elements[1:length])
+				return &object.Array{
+					Elements: newElements,
+				}
+			}
+			return NULL
+		},
+	},
+	"push": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `push` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+
+			newElements := make([]object.Object, length+1, length+1)
+			copy(newElements, arr.Elements)
+			newElements[length] = args[1]
+			return &object.Array{
+				Elements: newElements,
+			}
+		},
+	},
+	// pop returns [last, rest] where rest is a new array without the last
+	// element, the argument itself is left unchanged
+	"pop": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `pop` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length == 0 {
+				return newError("cannot pop from an empty array")
+			}
+
+			rest := make([]object.Object, length-1)
+			copy(rest, arr.Elements[:length-1])
+			return &object.Array{
+				Elements: []object.Object{
+					arr.Elements[length-1],
+					&object.Array{Elements: rest},
+				},
+			}
+		},
+	},
+	// insert places the value before the element at index, an index past
+	// either end of the array is clamped to it
+	"insert": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					3,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ || args[1].Type() != object.INTEGER_OBJ {
+				return newError("first two arguments to `insert` must be ARRAY, INTEGER, got=%s, %s",
+					args[0].Type(), args[1].Type())
+			}
+			elements := args[0].(*object.Array).Elements
+			idx := args[1].(*object.Integer).Value
+			length := int64(len(elements))
+			if idx < 0 {
+				idx = 0
+			} else if idx > length {
+				idx = length
+			}
+
+			newElements := make([]object.Object, 0, length+1)
+			newElements = append(newElements, elements[:idx]...)
+			newElements = append(newElements, args[2])
+			newElements = append(newElements, elements[idx:]...)
+			return &object.Array{Elements: newElements}
+		},
+	},
+	"removeAt": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ || args[1].Type() != object.INTEGER_OBJ {
+				return newError("arguments to `removeAt` must be ARRAY, INTEGER, got=%s, %s",
+					args[0].Type(), args[1].Type())
+			}
+			elements := args[0].(*object.Array).Elements
+			idx := args[1].(*object.Integer).Value
+			if idx < 0 || idx >= int64(len(elements)) {
+				return newError("index out of range: %d, length=%d", idx, len(elements))
+			}
+
+			newElements := make([]object.Object, 0, len(elements)-1)
+			newElements = append(newElements, elements[:idx]...)
+			newElements = append(newElements, elements[idx+1:]...)
+			return &object.Array{Elements: newElements}
+		},
+	},
+	"repeat": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if args[1].Type() != object.INTEGER_OBJ {
+				return newError("second argument to `repeat` must be INTEGER, got=%s",
+					args[1].Type())
+			}
+			single := &object.Array{Elements: []object.Object{args[0]}}
+			return evalArrayRepetition(single, args[1])
+		},
+	},
+	"print": {
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			out := outputOf(env)
+			for _, arg := range args {
+				fmt.Fprintln(out, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+	// input writes an optional prompt and reads a line, it returns null
+	// once the input is exhausted
+	"input": {
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=0 or 1",
+					len(args),
+				)
+			}
+			if len(args) == 1 {
+				prompt, ok := args[0].(*object.String)
+				if !ok {
+					return newError("argument to `input` must be STRING, got=%s",
+						args[0].Type())
+				}
+				fmt.Fprint(outputOf(env), prompt.Value)
+			}
+			line, err := inputOf(env).ReadString('\n')
+			if err == io.EOF && line == "" {
+				return NULL
+			}
+			if err != nil && err != io.EOF {
+				return newError("could not read input: %s", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			line = strings.TrimSuffix(line, "\r")
+			return &object.String{Value: line}
+		},
+	},
+	"assert": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=1 or 2",
+					len(args),
+				)
+			}
+			if isTruthy(args[0]) {
+				return NULL
+			}
+			if len(args) == 1 {
+				return newError("assertion failed")
+			}
+			if msg, ok := args[1].(*object.String); ok {
+				return newError("assertion failed: %s", msg.Value)
+			}
+			return newError("assertion failed: %s", args[1].Inspect())
+		},
+	},
+	// error(message, kind) raises an error of its own, kind is optional
+	"error": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=1 or 2",
+					len(args),
+				)
+			}
+			msg, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `error` must be STRING, got=%s",
+					args[0].Type())
+			}
+			err := &object.Error{Message: msg.Value}
+			if len(args) == 2 {
+				kind, ok := args[1].(*object.String)
+				if !ok {
+					return newError("second argument to `error` must be STRING, got=%s",
+						args[1].Type())
+				}
+				err.Kind = kind.Value
+			}
+			return err
+		},
+	},
+	"count": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			switch container := args[0].(type) {
+			case *object.Array:
+				count := 0
+				for _, el := range container.Elements {
+					if evalInfixExpression("==", el, args[1]) == TRUE {
+						count++
+					}
+				}
+				return &object.Integer{Value: int64(count)}
+			case *object.String:
+				sub, ok := args[1].(*object.String)
+				if !ok {
+					return newError("second argument to `count` must be STRING, got=%s",
+						args[1].Type())
+				}
+				return &object.Integer{
+					Value: int64(strings.Count(container.Value, sub.Value)),
+				}
+			default:
+				return newError(
+					"argument to `count` not supported, got=%s",
+					args[0].Type(),
+				)
+			}
+		},
+	},
+	"zip": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ || args[1].Type() != object.ARRAY_OBJ {
+				return newError("arguments to `zip` must be ARRAY, got=%s, %s",
+					args[0].Type(), args[1].Type())
+			}
+			left := args[0].(*object.Array).Elements
+			right := args[1].(*object.Array).Elements
+			length := len(left)
+			if len(right) < length {
+				length = len(right)
+			}
+
+			pairs := make([]object.Object, length)
+			for i := 0; i < length; i++ {
+				pairs[i] = &object.Array{
+					Elements: []object.Object{left[i], right[i]},
+				}
+			}
+			return &object.Array{Elements: pairs}
+		},
+	},
+	"enumerate": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `enumerate` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			elements := args[0].(*object.Array).Elements
+
+			pairs := make([]object.Object, len(elements))
+			for i, el := range elements {
+				pairs[i] = &object.Array{
+					Elements: []object.Object{&object.Integer{Value: int64(i)}, el},
+				}
+			}
+			return &object.Array{Elements: pairs}
+		},
+	},
+	"unique": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `unique` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			return &object.Array{Elements: uniqueElements(args[0].(*object.Array).Elements)}
+		},
+	},
+	"flatten": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=1 or 2",
+					len(args),
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError("argument to `flatten` must be ARRAY, got=%s",
+					args[0].Type())
+			}
+			depth := int64(1)
+			if len(args) == 2 {
+				if args[1].Type() != object.INTEGER_OBJ {
+					return newError("depth for `flatten` must be INTEGER, got=%s",
+						args[1].Type())
+				}
+				depth = args[1].(*object.Integer).Value
+				if depth < -1 {
+					return newError("invalid depth for `flatten`: %d", depth)
+				}
+			}
+			elements := flattenElements(nil, args[0].(*object.Array).Elements, depth)
+			return &object.Array{Elements: elements}
+		},
+	},
+	"take": sliceBuiltin("take", func(elements []object.Object, n int) []object.Object {
+		if n >= 0 {
+			return elements[:n]
+		}
+		return elements[len(elements)+n:]
+	}),
+	"drop": sliceBuiltin("drop", func(elements []object.Object, n int) []object.Object {
+		if n >= 0 {
+			return elements[n:]
+		}
+		return elements[:len(elements)+n]
+	}),
+	"keys": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.HASH_OBJ {
+				return newError("argument to `keys` must be HASH, got=%s",
+					args[0].Type())
+			}
+			pairs := args[0].(*object.Hash).OrderedPairs()
+			keys := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				keys[i] = pair.Key
+			}
+			return &object.Array{Elements: keys}
+		},
+	},
+	"values": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.HASH_OBJ {
+				return newError("argument to `values` must be HASH, got=%s",
+					args[0].Type())
+			}
+			pairs := args[0].(*object.Hash).OrderedPairs()
+			values := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				values[i] = pair.Value
+			}
+			return &object.Array{Elements: values}
+		},
+	},
+	"pairs": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.HASH_OBJ {
+				return newError("argument to `pairs` must be HASH, got=%s",
+					args[0].Type())
+			}
+			hashPairs := args[0].(*object.Hash).OrderedPairs()
+			pairs := make([]object.Object, len(hashPairs))
+			for i, pair := range hashPairs {
+				pairs[i] = &object.Array{
+					Elements: []object.Object{pair.Key, pair.Value},
+				}
+			}
+			return &object.Array{Elements: pairs}
+		},
+	},
+	"getOrDefault": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					3,
+				)
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `getOrDefault` must be HASH, got=%s",
+					args[0].Type())
+			}
+			key, ok := object.HashKeyOf(args[1])
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+			if pair, ok := hash.Pairs[key]; ok {
+				return pair.Value
+			}
+			return args[2]
+		},
+	},
+	"merge": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want at least 2",
+					len(args),
+				)
+			}
+			merged := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+			for _, arg := range args {
+				hash, ok := arg.(*object.Hash)
+				if !ok {
+					return newError("argument to `merge` must be HASH, got=%s",
+						arg.Type())
+				}
+				for _, pair := range hash.OrderedPairs() {
+					key, _ := object.HashKeyOf(pair.Key)
+					merged.Set(key, pair)
+				}
+			}
+			return merged
+		},
+	},
+	"clone": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			return cloneObject(args[0], map[object.Object]object.Object{})
+		},
+	},
+	// freeze marks an array or hash as immutable and returns it. Only the
+	// container itself is frozen, values nested inside it are not.
+	"freeze": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			switch arg := args[0].(type) {
+			case *object.Array:
+				arg.Frozen = true
+			case *object.Hash:
+				arg.Frozen = true
+			default:
+				return newError("argument to `freeze` must be ARRAY or HASH, got=%s",
+					args[0].Type())
+			}
+			return args[0]
+		},
+	},
+	"toJSON": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			var out bytes.Buffer
+			if err := writeJSON(&out, args[0]); err != nil {
+				return err
+			}
+			return &object.String{Value: out.String()}
+		},
+	},
+	"fromJSON": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.STRING_OBJ {
+				return newError("argument to `fromJSON` must be STRING, got=%s",
+					args[0].Type())
+			}
+			return parseJSON(args[0].(*object.String).Value)
+		},
+	},
+	"sqrt": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if !isNumber(args[0]) {
+				return newError("argument to `sqrt` must be INTEGER or FLOAT, got=%s",
+					args[0].Type())
+			}
+			value := toFloat(args[0])
+			if value < 0 {
+				return newError("square root of negative number: %s", args[0].Inspect())
+			}
+			return &object.Float{Value: math.Sqrt(value)}
+		},
+	},
+	"pow": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if !isNumber(args[0]) || !isNumber(args[1]) {
+				return newError("arguments to `pow` must be INTEGER or FLOAT, got=%s, %s",
+					args[0].Type(), args[1].Type())
+			}
+			// integer powers stay exact, anything else is computed as float
+			if isInteger(args[0]) && args[1].Type() == object.INTEGER_OBJ &&
+				args[1].(*object.Integer).Value >= 0 {
+				base, exp := toBigInt(args[0]), args[1].(*object.Integer).Value
+				// 0, 1 and -1 stay small whatever the exponent
+				if bits := base.BitLen(); bits > 1 && exp > maxPowBits/int64(bits) {
+					return newError("result of `pow` is too large")
+				}
+				result := new(big.Int).Exp(base, big.NewInt(exp), nil)
+				return normalizeBigInt(result)
+			}
+			return &object.Float{Value: math.Pow(toFloat(args[0]), toFloat(args[1]))}
+		},
+	},
+	"pi": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					0,
+				)
+			}
+			return &object.Float{Value: math.Pi}
+		},
+	},
+	"rand": {
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					0,
+				)
+			}
+			return &object.Float{Value: attachState(env).rand().Float64()}
+		},
+	},
+	"randInt": {
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if args[0].Type() != object.INTEGER_OBJ || args[1].Type() != object.INTEGER_OBJ {
+				return newError("arguments to `randInt` must be INTEGER, got=%s, %s",
+					args[0].Type(), args[1].Type())
+			}
+			lo := args[0].(*object.Integer).Value
+			hi := args[1].(*object.Integer).Value
+			if lo > hi {
+				return newError("invalid range for `randInt`: %d > %d", lo, hi)
+			}
+			// both bounds are inclusive
+			span := uint64(hi-lo) + 1
+			if span == 0 || span > math.MaxInt64 {
+				return newError("range for `randInt` is too large")
+			}
+			n := attachState(env).rand().Int63n(int64(span))
+			return &object.Integer{Value: lo + n}
+		},
+	},
+	"seed": {
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if args[0].Type() != object.INTEGER_OBJ {
+				return newError("argument to `seed` must be INTEGER, got=%s",
+					args[0].Type())
+			}
+			attachState(env).seed(args[0].(*object.Integer).Value)
+			return NULL
+		},
+	},
+	// unset removes a binding from the scope it is called in and reports
+	// whether there was one
+	"unset": {
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `unset` must be STRING, got=%s",
+					args[0].Type())
+			}
+			return object.NativeBool(env.Delete(name.Value))
+		},
+	},
+	"isSame": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			return object.NativeBool(isSame(args[0], args[1]))
+		},
+	},
+	"toBase": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if !isInteger(args[0]) {
+				return newError("first argument to `toBase` must be INTEGER, got=%s",
+					args[0].Type())
+			}
+			base, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("second argument to `toBase` must be INTEGER, got=%s",
+					args[1].Type())
+			}
+			if base.Value < 2 || base.Value > 36 {
+				return newError("invalid base for `toBase`: %d, want 2 to 36", base.Value)
+			}
+			if n, ok := args[0].(*object.BigInt); ok {
+				return &object.String{Value: n.Value.Text(int(base.Value))}
+			}
+			n := args[0].(*object.Integer).Value
+			return &object.String{Value: strconv.FormatInt(n, int(base.Value))}
+		},
+	},
+	"parseInt": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `parseInt` must be STRING, got=%s",
+					args[0].Type())
+			}
+			base, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("second argument to `parseInt` must be INTEGER, got=%s",
+					args[1].Type())
+			}
+			if base.Value < 2 || base.Value > 36 {
+				return newError("invalid base for `parseInt`: %d, want 2 to 36", base.Value)
+			}
+			n, err := strconv.ParseInt(s.Value, int(base.Value), 64)
+			if errors.Is(err, strconv.ErrRange) {
+				if n, ok := new(big.Int).SetString(s.Value, int(base.Value)); ok {
+					return &object.BigInt{Value: n}
+				}
+			}
+			if err != nil {
+				return newError("could not parse %q as integer in base %d",
+					s.Value, base.Value)
+			}
+			return &object.Integer{Value: n}
+		},
+	},
+	"round":      roundingBuiltin("round", math.Round),
+	"floor":      roundingBuiltin("floor", math.Floor),
+	"ceil":       roundingBuiltin("ceil", math.Ceil),
+	"isInt":      typePredicate(object.INTEGER_OBJ, object.BIGINT_OBJ),
+	"isString":   typePredicate(object.STRING_OBJ),
+	"isArray":    typePredicate(object.ARRAY_OBJ),
+	"isHash":     typePredicate(object.HASH_OBJ),
+	"isFunction": typePredicate(object.FUNCTION_OBJ, object.BUILTIN_OBJ),
+	"isNull":     typePredicate(object.NULL_OBJ),
+}
+
+// typePredicate builds a builtin reporting whether its argument is one of types
+func typePredicate(types ...object.ObjectType) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			for _, t := range types {
+				if args[0].Type() == t {
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	}
+}
+
+// isSame reports whether a and b are the same value: scalars of the same
+// type holding equal values, or else the very same object. Unlike == it
+// never converts between number types, and two arrays, hashes or functions
+// are only the same when they are one object.
+func isSame(a, b object.Object) bool {
+	switch a := a.(type) {
+	case *object.Integer:
+		b, ok := b.(*object.Integer)
+		return ok && a.Value == b.Value
+	case *object.BigInt:
+		b, ok := b.(*object.BigInt)
+		return ok && a.Value.Cmp(b.Value) == 0
+	case *object.Float:
+		b, ok := b.(*object.Float)
+		return ok && a.Value == b.Value
+	case *object.String:
+		b, ok := b.(*object.String)
+		return ok && a.Value == b.Value
+	default:
+		return a == b
+	}
+}
+
+// uniqueElements drops elements equal (by ==) to an earlier one. Hashable
+// elements are looked up by hash key; the rest, which may still equal a
+// hashable element (1.0 == 1), are compared one by one.
+func uniqueElements(elements []object.Object) []object.Object {
+	result := []object.Object{}
+	seen := map[object.HashKey]bool{}
+	unhashed := []object.Object{}
+
+	contains := func(candidates []object.Object, el object.Object) bool {
+		for _, c := range candidates {
+			if evalInfixExpression("==", c, el) == TRUE {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, el := range elements {
+		// arrays have hash keys but == compares them by identity, so they
+		// take the slow path
+		if key, ok := el.(object.Hashable); ok {
+			if seen[key.HashKey()] || contains(unhashed, el) {
+				continue
+			}
+			seen[key.HashKey()] = true
+		} else {
+			if contains(result, el) {
+				continue
+			}
+			unhashed = append(unhashed, el)
+		}
+		result = append(result, el)
+	}
+	return result
+}
+
+// cloneObject deep copies arrays and hashes, other values are immutable and
+// returned as they are. Copies are never frozen. A container reached twice
+// is copied once, so shared parts stay shared in the copy and cycles do not
+// recurse forever.
+func cloneObject(obj object.Object, copies map[object.Object]object.Object) object.Object {
+	if copied, ok := copies[obj]; ok {
+		return copied
+	}
+	switch obj := obj.(type) {
+	case *object.Array:
+		copied := &object.Array{Elements: make([]object.Object, len(obj.Elements))}
+		copies[obj] = copied
+		for i, el := range obj.Elements {
+			copied.Elements[i] = cloneObject(el, copies)
+		}
+		return copied
+	case *object.Hash:
+		copied := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(obj.Pairs))}
+		copies[obj] = copied
+		for _, pair := range obj.OrderedPairs() {
+			key, _ := object.HashKeyOf(pair.Key)
+			copied.Set(key, object.HashPair{
+				Key:   cloneObject(pair.Key, copies),
+				Value: cloneObject(pair.Value, copies),
+			})
+		}
+		return copied
+	default:
+		return obj
+	}
+}
+
+// flattenElements appends elements to result, splicing in nested arrays up to
+// depth levels deep, or all the way down when depth is -1
+func flattenElements(result, elements []object.Object, depth int64) []object.Object {
+	for _, el := range elements {
+		nested, ok := el.(*object.Array)
+		if !ok || depth == 0 {
+			result = append(result, el)
+			continue
+		}
+		result = flattenElements(result, nested.Elements, depth-1)
+	}
+	if result == nil {
+		return []object.Object{}
+	}
+	return result
+}
+
+// sliceBuiltin builds take and drop. The count is clamped to the length of
+// the array before slice is called; a negative count works from the end.
+func sliceBuiltin(
+	name string,
+	slice func(elements []object.Object, n int) []object.Object,
+) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					2,
+				)
+			}
+			if args[0].Type() != object.ARRAY_OBJ || args[1].Type() != object.INTEGER_OBJ {
+				return newError("arguments to `%s` must be ARRAY, INTEGER, got=%s, %s",
+					name, args[0].Type(), args[1].Type())
+			}
+			elements := args[0].(*object.Array).Elements
+			n := args[1].(*object.Integer).Value
+			length := int64(len(elements))
+			if n > length {
+				n = length
+			} else if n < -length {
+				n = -length
+			}
+
+			sliced := slice(elements, int(n))
+			result := make([]object.Object, len(sliced))
+			copy(result, sliced)
+			return &object.Array{Elements: result}
+		},
+	}
+}
+
+// roundingBuiltin builds a builtin that rounds a float to an integer with
+// round, integers are returned unchanged
+func roundingBuiltin(name string, round func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments: got=%d, want=%d",
+					len(args),
+					1,
+				)
+			}
+			if isInteger(args[0]) {
+				return args[0]
+			}
+			if args[0].Type() != object.FLOAT_OBJ {
+				return newError("argument to `%s` must be INTEGER or FLOAT, got=%s",
+					name, args[0].Type())
+			}
+			value := round(args[0].(*object.Float).Value)
+			if math.IsInf(value, 0) || math.IsNaN(value) {
+				return newError("cannot convert %s to integer", args[0].Inspect())
+			}
+			integer, _ := big.NewFloat(value).Int(nil)
+			return normalizeBigInt(integer)
+		},
+	}
+}
+
+// RegisterBuiltin makes a host function callable from scripts under name.
+// Registering a name that is already taken is an error, so the standard
+// builtins cannot be replaced. It must not be called while scripts run.
+func RegisterBuiltin(name string, fn object.BuiltinFunction) error {
+	if _, ok := builtins[name]; ok {
+		return fmt.Errorf("builtin %s is already defined", name)
+	}
+	builtins[name] = &object.Builtin{Fn: fn}
+	return nil
+}
+
+// RegisterEnvBuiltin is like RegisterBuiltin for functions that need the
+// environment of the call site
+func RegisterEnvBuiltin(name string, fn object.BuiltinEnvFunction) error {
+	if _, ok := builtins[name]; ok {
+		return fmt.Errorf("builtin %s is already defined", name)
+	}
+	builtins[name] = &object.Builtin{EnvFn: fn}
+	return nil
+}
+
+// BuiltinNames returns the names of every builtin, including registered
+// ones, in alphabetical order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Builtin returns a copy of the builtin called name, so changing it does not
+// affect scripts
+func Builtin(name string) (*object.Builtin, bool) {
+	builtin, ok := builtins[name]
+	if !ok {
+		return nil, false
+	}
+	copied := *builtin
+	return &copied, true
+}
@@ -0,0 +1,152 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+func TestRegisterBuiltin(t *testing.T) {
+	err := RegisterBuiltin("shout", func(args ...object.Object) object.Object {
+		if len(args) != 1 || args[0].Type() != object.STRING_OBJ {
+			return newError("argument to `shout` must be STRING")
+		}
+		return &object.String{Value: strings.ToUpper(args[0].(*object.String).Value)}
+	})
+	if err != nil {
+		t.Fatalf("RegisterBuiltin returned error: %s", err)
+	}
+	t.Cleanup(func() { delete(builtins, "shout") })
+
+	testStringObject(t, testEval(`shout("hello") + "!"`), "HELLO!")
+	testErrorObject(t, testEval(`shout(1)`), "argument to `shout` must be STRING")
+
+	// bindings still shadow registered builtins
+	testIntegerObject(t, testEval(`let shout = 5; shout`), 5)
+}
+
+func TestRegisterBuiltinCollision(t *testing.T) {
+	err := RegisterBuiltin("len", func(args ...object.Object) object.Object {
+		return NULL
+	})
+	if err == nil {
+		t.Fatalf("expected an error registering over an existing builtin")
+	}
+	if err.Error() != "builtin len is already defined" {
+		t.Errorf("err.Error(): expected=%q, got=%q",
+			"builtin len is already defined", err.Error())
+	}
+	testIntegerObject(t, testEval(`len("four")`), 4)
+}
+
+func TestBuiltinNames(t *testing.T) {
+	names := BuiltinNames()
+	for _, name := range []string{"len", "first", "push", "each", "print"} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("BuiltinNames() does not include %q", name)
+		}
+	}
+	if len(names) != len(builtins) {
+		t.Errorf("len(BuiltinNames()): expected=%d, got=%d", len(builtins), len(names))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("names not sorted: %q before %q", names[i-1], names[i])
+		}
+	}
+
+	err := RegisterBuiltin("registeredName", func(args ...object.Object) object.Object {
+		return NULL
+	})
+	if err != nil {
+		t.Fatalf("RegisterBuiltin returned error: %s", err)
+	}
+	t.Cleanup(func() { delete(builtins, "registeredName") })
+	if len(BuiltinNames()) != len(names)+1 {
+		t.Errorf("registered builtin missing from BuiltinNames()")
+	}
+}
+
+func TestBuiltinLookup(t *testing.T) {
+	builtin, ok := Builtin("len")
+	if !ok {
+		t.Fatalf("Builtin(%q) not found", "len")
+	}
+	testIntegerObject(t, builtin.Fn(&object.String{Value: "four"}), 4)
+
+	// the result is a copy, replacing its function leaves scripts alone
+	builtin.Fn = func(args ...object.Object) object.Object { return NULL }
+	testIntegerObject(t, testEval(`len("four")`), 4)
+
+	if _, ok := Builtin("noSuchBuiltin"); ok {
+		t.Errorf("Builtin(%q) found", "noSuchBuiltin")
+	}
+}
+
+func TestBuiltinReceivesEnvironment(t *testing.T) {
+	err := RegisterEnvBuiltin("lookup", func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 || args[0].Type() != object.STRING_OBJ {
+			return newError("argument to `lookup` must be STRING")
+		}
+		name := args[0].(*object.String).Value
+		if val, ok := env.Get(name); ok {
+			return val
+		}
+		return NULL
+	})
+	if err != nil {
+		t.Fatalf("RegisterEnvBuiltin returned error: %s", err)
+	}
+	t.Cleanup(func() { delete(builtins, "lookup") })
+
+	testIntegerObject(t, testEval(`let answer = 42; lookup("answer")`), 42)
+	// the builtin sees the scope it is called from
+	testIntegerObject(t, testEval(`let f = fn(local) { lookup("local") }; f(7)`), 7)
+	testNullObject(t, testEval(`let f = fn(local) { 1 }; f(7); lookup("local")`))
+
+	if err := RegisterEnvBuiltin("len", nil); err == nil {
+		t.Errorf("expected an error registering over an existing builtin")
+	}
+}
+
+func TestIsSame(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"isSame(1, 1)", true},
+		{"isSame(1, 2)", false},
+		{`isSame("a", "a")`, true},
+		{"isSame(1.5, 1.5)", true},
+		{"isSame(99999999999999999999, 99999999999999999999)", true},
+		{"isSame(true, true)", true},
+		{"isSame(print(), print())", true},
+		// == converts between number types, isSame does not
+		{"1 == 1.0", true},
+		{"isSame(1, 1.0)", false},
+		{`isSame(1, "1")`, false},
+		// containers and functions are the same only when they are one object
+		{"[1] == [1]", false},
+		{"isSame([1], [1])", false},
+		{"let a = [1]; isSame(a, a)", true},
+		{"let a = [1]; let b = a; isSame(a, b)", true},
+		{"isSame(clone([1]), [1])", false},
+		{`let h = {"a": 1}; isSame(h, h)`, true},
+		{"fn() {} == fn() {}", false},
+		{"isSame(fn() {}, fn() {})", false},
+		{"let f = fn() {}; f == f", true},
+		{"let f = fn() {}; isSame(f, f)", true},
+		{"let table = [len, first]; isSame(table[0], len)", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+
+	testErrorObject(t, testEval("isSame(1)"), "wrong number of arguments: got=1, want=2")
+}
@@ -1,157 +1,48 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 
 	"github.com/anukuljoshi/monkey/ast"
 	"github.com/anukuljoshi/monkey/object"
+	"github.com/anukuljoshi/monkey/token"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL      = object.NULL
+	UNDEFINED = &object.Undefined{}
+	TRUE      = object.TRUE
+	FALSE     = object.FALSE
+	BREAK     = &object.Break{}
+	CONTINUE  = &object.Continue{}
 )
 
-var builtins = map[string]*object.Builtin{
-	"len": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError(
-					"wrong number of arguments: got=%d, want=%d",
-					len(args),
-					1,
-				)
-			}
-			switch arg := args[0].(type) {
-			case *object.String:
-				return &object.Integer{
-					Value: int64(len(arg.Value)),
-				}
-			case *object.Array:
-				return &object.Integer{
-					Value: int64(len(arg.Elements)),
-				}
-			default:
-				return newError(
-					"argument to `len` not supported, got=%s",
-					args[0].Type(),
-				)
-			}
-		},
-	},
-	"first": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError(
-					"wrong number of arguments: got=%d, want=%d",
-					len(args),
-					1,
-				)
-			}
-			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must be ARRAY, got=%s",
-					args[0].Type())
-			}
-			arr := args[0].(*object.Array)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[0]
-			}
-			return NULL
-		},
-	},
-	"last": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError(
-					"wrong number of arguments: got=%d, want=%d",
-					len(args),
-					1,
-				)
-			}
-			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `last` must be ARRAY, got=%s",
-					args[0].Type())
-			}
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[length-1]
-			}
-			return NULL
-		},
-	},
-	"rest": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError(
-					"wrong number of arguments: got=%d, want=%d",
-					len(args),
-					1,
-				)
-			}
-			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `rest` must be ARRAY, got=%s",
-					args[0].Type())
-			}
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			if length > 0 {
-				newElements := make([]object.Object, length-1, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &object.Array{
-					Elements: newElements,
-				}
-			}
-			return NULL
-		},
-	},
-	"push": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError(
-					"wrong number of arguments: got=%d, want=%d",
-					len(args),
-					2,
-				)
-			}
-			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `push` must be ARRAY, got=%s",
-					args[0].Type())
-			}
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-
-			newElements := make([]object.Object, length+1, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
-			return &object.Array{
-				Elements: newElements,
-			}
-		},
-	},
-	"print": {
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
-			return NULL
-		},
-	},
-}
-
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if state := stateOf(env); state != nil {
+		if err := state.step(); err != nil {
+			return err
+		}
+	}
+
 	switch node := node.(type) {
 	// statements
 	case *ast.Program:
-		return evalProgram(node.Statements, env)
+		return evalProgram(node.Statements, env, nil)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
 	// expressions
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.BigIntegerLiteral:
+		return &object.BigInt{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
-		return nativeBoolToBooleanObject(node.Value)
+		return object.NativeBool(node.Value)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -159,6 +50,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if node.Token.Type == token.AND || node.Token.Type == token.OR {
+			return evalLogicalExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -167,51 +61,96 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
+		if result, ok := coerceStrings(node.Operator, left, right, env); ok {
+			return result
+		}
 		return evalInfixExpression(node.Operator, left, right)
+	case *ast.ChainedComparison:
+		return evalChainedComparison(node, env)
+	case *ast.PostfixExpression:
+		return evalPostfixExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.IndexAssignExpression:
+		return evalIndexAssignExpression(node, env)
 	case *ast.BlockStatement:
 		return evalBlockStatements(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.SwitchExpression:
+		return evalSwitchExpression(node, env)
+	case *ast.WhileExpression:
+		return evalWhileExpression(node, env)
+	case *ast.DoWhileExpression:
+		return evalDoWhileExpression(node, env)
+	case *ast.ForExpression:
+		return evalForExpression(node, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: NULL}
+		}
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.LetStatement:
+		if err := checkBinding(node.Name.Value, env); err != nil {
+			return err
+		}
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		return env.Set(node.Name.Value, val)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
+		if err := checkFunctionBindings(node, env); err != nil {
+			return err
+		}
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{
+		function := &object.Function{
 			Parameters: params,
 			Body:       body,
 			Env:        env,
 		}
+		if node.Name != nil {
+			// a named function sees itself in its closure, without leaking
+			// the name into the scope it was created in
+			function.Env = object.NewEnclosedEnvironment(env)
+			function.Env.Set(node.Name.Value, function)
+		}
+		return function
 	case *ast.CallExpression:
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
+		args, err := evalExpressions(node.Arguments, env)
+		if err != nil {
+			return err
 		}
-		return applyFunction(function, args)
+		return applyFunction(function, args, env)
 	case *ast.StringLiteral:
 		return &object.String{
 			Value: node.Value,
 		}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
-			return elements[0]
+		elements, err := evalExpressions(node.Elements, env)
+		if err != nil {
+			return err
 		}
 		return &object.Array{
 			Elements: elements,
@@ -229,43 +168,109 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	}
-	return nil
+	return NULL
 }
 
 // ast.Program helpers
-func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
-	var result object.Object
 
+// EvalStream evaluates program like Eval, calling each with the result of
+// every top level statement as soon as it is known. A return, or an error,
+// is the last result passed to each.
+func EvalStream(
+	program *ast.Program,
+	env *object.Environment,
+	each func(object.Object),
+) object.Object {
+	return evalProgram(program.Statements, env, each)
+}
+
+// evalProgram evaluates the top level statements, each is called with the
+// result of every statement unless it is nil. The value of a program is
+// that of its last statement, where lets and assignments count as null so
+// a host can tell there is nothing to show.
+func evalProgram(
+	stmts []ast.Statement,
+	env *object.Environment,
+	each func(object.Object),
+) object.Object {
+	var result object.Object = NULL
+
+	hoistFunctions(stmts, env)
 	for _, stmt := range stmts {
 		result = Eval(stmt, env)
 
-		switch result := result.(type) {
+		stop := true
+		switch r := result.(type) {
 		case *object.ReturnValue:
-			return result.Value
+			result = r.Value
 		case *object.Error:
-			return result
+		case *object.Break, *object.Continue:
+			result = loopControlError(r)
+		default:
+			stop = false
+			if IsBinding(stmt) {
+				result = NULL
+			}
+		}
+		if each != nil {
+			each(result)
 		}
-		if returnValue, ok := result.(*object.ReturnValue); ok {
-			return returnValue.Value
+		if stop {
+			return result
 		}
 	}
 
 	return result
 }
 
+// IsBinding reports whether stmt is a let, an import or an assignment. At
+// the top level of a program these evaluate to null, and a REPL can use it
+// to tell that there is no value to echo.
+func IsBinding(stmt ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement, *ast.ImportStatement:
+		return true
+	case *ast.ExpressionStatement:
+		switch stmt.Expression.(type) {
+		case *ast.AssignExpression, *ast.IndexAssignExpression:
+			return true
+		}
+	}
+	return false
+}
+
+// hoistFunctions binds the names of top level lets whose value is a function
+// literal before anything runs, so functions can call ones defined further
+// down even when the call happens before that let is reached. The let
+// statements still run in order and rebind their names as usual.
+func hoistFunctions(stmts []ast.Statement, env *object.Environment) {
+	for _, stmt := range stmts {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || checkBinding(let.Name.Value, env) != nil {
+			continue
+		}
+		if fn, ok := let.Value.(*ast.FunctionLiteral); ok {
+			if function := Eval(fn, env); !isError(function) {
+				env.Set(let.Name.Value, function)
+			}
+		}
+	}
+}
+
 // block statements
 func evalBlockStatements(
 	block *ast.BlockStatement,
 	env *object.Environment,
 ) object.Object {
-	var result object.Object
+	var result object.Object = NULL
 
 	for _, statement := range block.Statements {
 		result = Eval(statement, env)
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ ||
+				rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -273,21 +278,15 @@ func evalBlockStatements(
 	return result
 }
 
-// ast.Boolean helpers
-func nativeBoolToBooleanObject(input bool) *object.Boolean {
-	if input {
-		return TRUE
-	}
-	return FALSE
-}
-
 // ast.Prefix helpers
 func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
-	case "!":
+	case "!", "not":
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "+":
+		return evalPlusPrefixOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -299,7 +298,7 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 		return FALSE
 	case FALSE:
 		return TRUE
-	case NULL:
+	case NULL, UNDEFINED:
 		return TRUE
 	default:
 		return FALSE
@@ -307,28 +306,184 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() == object.BIGINT_OBJ {
+		value := right.(*object.BigInt).Value
+		return normalizeBigInt(new(big.Int).Neg(value))
+	}
+	if right.Type() == object.FLOAT_OBJ {
+		return &object.Float{Value: -right.(*object.Float).Value}
+	}
 	if right.Type() != object.INTEGER_OBJ {
 		return newError("unknown operator: -%s", right.Type())
 	}
 
 	value := right.(*object.Integer).Value
+	// -MinInt64 does not fit in int64, promote it like any other overflow
+	if value == math.MinInt64 {
+		return normalizeBigInt(new(big.Int).Neg(big.NewInt(value)))
+	}
 	return &object.Integer{Value: -value}
 }
 
+// unary plus only checks that its operand is a number
+func evalPlusPrefixOperatorExpression(right object.Object) object.Object {
+	if !isNumber(right) {
+		return newError("unknown operator: +%s", right.Type())
+	}
+	return right
+}
+
+// ast.Postfix helpers
+
+// evalPostfixExpression steps an integer binding by one and, like its C
+// counterpart, evaluates to the value held before the update
+func evalPostfixExpression(
+	node *ast.PostfixExpression,
+	env *object.Environment,
+) object.Object {
+	current := evalIdentifier(node.Left, env)
+	if isError(current) {
+		return current
+	}
+	if !isInteger(current) {
+		return newError("unknown operator: %s%s", current.Type(), node.Operator)
+	}
+
+	one := &object.Integer{Value: 1}
+	var updated object.Object
+	switch node.Operator {
+	case "++":
+		updated = evalInfixExpression("+", current, one)
+	case "--":
+		updated = evalInfixExpression("-", current, one)
+	default:
+		return newError("unknown operator: %s%s", current.Type(), node.Operator)
+	}
+
+	if _, ok := env.Assign(node.Left.Value, updated); !ok {
+		return newError("identifier not found: %s", node.Left.Value)
+	}
+	return current
+}
+
+// assignment
+func evalAssignExpression(
+	node *ast.AssignExpression,
+	env *object.Environment,
+) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	if _, ok := env.Assign(node.Name.Value, val); !ok {
+		return newError("identifier not found: %s", node.Name.Value)
+	}
+	return val
+}
+
+// the container and index are evaluated before the value, like the operands
+// of an index expression
+func evalIndexAssignExpression(
+	node *ast.IndexAssignExpression,
+	env *object.Environment,
+) object.Object {
+	container := Eval(node.Left.Left, env)
+	if isError(container) {
+		return container
+	}
+	index := Eval(node.Left.Index, env)
+	if isError(index) {
+		return index
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	switch container := container.(type) {
+	case *object.Array:
+		if container.Frozen {
+			return newError("cannot modify frozen value")
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got=%s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(container.Elements)) {
+			return newError("index out of range: %d, length=%d",
+				idx.Value, len(container.Elements))
+		}
+		if reaches(val, container, map[object.Object]bool{}) {
+			return newError("cannot store an array inside itself")
+		}
+		container.Elements[idx.Value] = val
+	case *object.Hash:
+		if container.Frozen {
+			return newError("cannot modify frozen value")
+		}
+		key, ok := object.HashKeyOf(index)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		if reaches(val, container, map[object.Object]bool{}) {
+			return newError("cannot store a hash inside itself")
+		}
+		container.Set(key, object.HashPair{Key: index, Value: val})
+	default:
+		return newError("index assignment not supported: %s", container.Type())
+	}
+	return val
+}
+
+// reaches reports whether target is val or is nested somewhere inside it.
+// Index assignment refuses to create cycles, which Inspect and the builtins
+// walking nested values would never get out of.
+func reaches(val, target object.Object, seen map[object.Object]bool) bool {
+	if val == target {
+		return true
+	}
+	if seen[val] {
+		return false
+	}
+	switch val := val.(type) {
+	case *object.Array:
+		seen[val] = true
+		for _, el := range val.Elements {
+			if reaches(el, target, seen) {
+				return true
+			}
+		}
+	case *object.Hash:
+		seen[val] = true
+		for _, pair := range val.Pairs {
+			if reaches(pair.Key, target, seen) || reaches(pair.Value, target, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ast.Infix helpers
 func evalInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
+	if operator == "in" {
+		return evalInExpression(left, right)
+	}
+	if fn, ok := lookupInfix(operator, left, right); ok {
+		return fn(operator, left, right)
+	}
+
+	// without a handler, as for arrays, hashes and functions, == compares
+	// identity: two function literals are never equal even with the same
+	// body, while a function always equals itself
 	switch {
-	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
-	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return object.NativeBool(left == right)
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return object.NativeBool(left != right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s",
 			left.Type(), operator, right.Type())
@@ -347,27 +502,227 @@ func evalIntegerInfixExpression(
 
 	switch operator {
 	case "+":
+		if addOverflows(leftVal, rightVal) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal + rightVal}
 	case "-":
+		if subOverflows(leftVal, rightVal) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal - rightVal}
 	case "*":
+		if mulOverflows(leftVal, rightVal) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
+		return object.NativeBool(leftVal > rightVal)
+	case "<":
+		return object.NativeBool(leftVal < rightVal)
+	case "<=":
+		return object.NativeBool(leftVal <= rightVal)
+	case ">=":
+		return object.NativeBool(leftVal >= rightVal)
+	case "==":
+		return object.NativeBool(leftVal == rightVal)
+	case "!=":
+		return object.NativeBool(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// big integers
+func evalBigIntInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := toBigInt(left)
+	rightVal := toBigInt(right)
+
+	switch operator {
+	case "+":
+		return normalizeBigInt(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return normalizeBigInt(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return normalizeBigInt(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return normalizeBigInt(new(big.Int).Quo(leftVal, rightVal))
+	case ">":
+		return object.NativeBool(leftVal.Cmp(rightVal) > 0)
+	case "<":
+		return object.NativeBool(leftVal.Cmp(rightVal) < 0)
+	case "<=":
+		return object.NativeBool(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return object.NativeBool(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return object.NativeBool(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return object.NativeBool(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// logical operators short-circuit, the right operand is only evaluated
+// when the left one does not settle the result
+func evalLogicalExpression(
+	node *ast.InfixExpression,
+	env *object.Environment,
+) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	if node.Token.Type == token.AND && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Token.Type == token.OR && isTruthy(left) {
+		return TRUE
+	}
+
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return object.NativeBool(isTruthy(right))
+}
+
+// evalChainedComparison evaluates every operand at most once, from left to
+// right, and stops at the first comparison that does not hold
+func evalChainedComparison(
+	node *ast.ChainedComparison,
+	env *object.Environment,
+) object.Object {
+	left := Eval(node.Operands[0], env)
+	if isError(left) {
+		return left
+	}
+	for i, operator := range node.Operators {
+		right := Eval(node.Operands[i+1], env)
+		if isError(right) {
+			return right
+		}
+		result := evalInfixExpression(operator, left, right)
+		if isError(result) {
+			return result
+		}
+		if !isTruthy(result) {
+			return FALSE
+		}
+		left = right
+	}
+	return TRUE
+}
+
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
+
+func isNumber(obj object.Object) bool {
+	return isInteger(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+// float arithmetic, an integer operand is converted to float first
+func evalFloatInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case ">":
+		return object.NativeBool(leftVal > rightVal)
 	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
+		return object.NativeBool(leftVal < rightVal)
+	case "<=":
+		return object.NativeBool(leftVal <= rightVal)
+	case ">=":
+		return object.NativeBool(leftVal >= rightVal)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return object.NativeBool(leftVal == rightVal)
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return object.NativeBool(leftVal != rightVal)
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	default:
+		return obj.(*object.Float).Value
+	}
+}
+
+func toBigInt(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	}
+	return nil
+}
+
+// normalizeBigInt demotes results that fit in int64 back to an Integer so
+// that small values always share one representation
+func normalizeBigInt(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInt{Value: value}
+}
+
+func addOverflows(a, b int64) bool {
+	return (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)
+}
+
+func subOverflows(a, b int64) bool {
+	return (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b)
+}
+
+func mulOverflows(a, b int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return true
+	}
+	return (a*b)/b != a
+}
+
 // string concat
 func evalStringInfixExpression(
 	operator string,
@@ -380,19 +735,125 @@ func evalStringInfixExpression(
 	case "+":
 		return &object.String{Value: leftVal + rightVal}
 	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
+		return object.NativeBool(leftVal > rightVal)
 	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
+		return object.NativeBool(leftVal < rightVal)
+	case "<=":
+		return object.NativeBool(leftVal <= rightVal)
+	case ">=":
+		return object.NativeBool(leftVal >= rightVal)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return object.NativeBool(leftVal == rightVal)
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return object.NativeBool(leftVal != rightVal)
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
+// maxRepetitionLength caps the length of a repeated string or array, so a
+// large count is an error rather than exhausting memory
+const maxRepetitionLength = 1 << 26
+
+// maxPowBits caps the size of an integer power, which is computed in a
+// single step that neither MaxSteps nor a context can interrupt
+const maxPowBits = 1 << 20
+
+// checkRepetition reports an error unless n copies of length items fit
+// within maxRepetitionLength
+func checkRepetition(length int, n int64) *object.Error {
+	if n < 0 {
+		return newError("negative repetition count: %d", n)
+	}
+	if length > 0 && n > int64(maxRepetitionLength/length) {
+		return newError("repetition result too large: %d * %d", length, n)
+	}
+	return nil
+}
+
+// string repetition, "ab" * 3 and 3 * "ab" both give "ababab"
+func evalStringRepetition(str, count object.Object) object.Object {
+	value := str.(*object.String).Value
+	n := count.(*object.Integer).Value
+	if err := checkRepetition(len(value), n); err != nil {
+		return err
+	}
+	return &object.String{Value: strings.Repeat(value, int(n))}
+}
+
+// array concatenation, the result is a new array holding the same elements,
+// nested arrays and hashes are shared rather than copied
+func evalArrayConcatenation(left, right object.Object) object.Object {
+	leftElements := left.(*object.Array).Elements
+	rightElements := right.(*object.Array).Elements
+
+	elements := make([]object.Object, 0, len(leftElements)+len(rightElements))
+	elements = append(elements, leftElements...)
+	elements = append(elements, rightElements...)
+	return &object.Array{Elements: elements}
+}
+
+// array repetition, like concatenation the copies share nested values
+func evalArrayRepetition(arr, count object.Object) object.Object {
+	source := arr.(*object.Array).Elements
+	n := count.(*object.Integer).Value
+	if err := checkRepetition(len(source), n); err != nil {
+		return err
+	}
+
+	elements := make([]object.Object, 0, len(source)*int(n))
+	for i := int64(0); i < n && len(source) > 0; i++ {
+		elements = append(elements, source...)
+	}
+	return &object.Array{Elements: elements}
+}
+
+// membership test, value equality for array elements and key presence
+// for hashes
+func evalInExpression(needle, haystack object.Object) object.Object {
+	switch haystack := haystack.(type) {
+	case *object.Array:
+		for _, el := range haystack.Elements {
+			if evalInfixExpression("==", needle, el) == TRUE {
+				return TRUE
+			}
+		}
+		return FALSE
+	case *object.Hash:
+		key, ok := object.HashKeyOf(needle)
+		if !ok {
+			return newError("unusable as hash key: %s", needle.Type())
+		}
+		_, ok = haystack.Pairs[key]
+		return object.NativeBool(ok)
+	default:
+		return newError("operator in not supported: %s in %s",
+			needle.Type(), haystack.Type())
+	}
+}
+
+// interpolated strings, non-string values are rendered with Inspect()
+func evalInterpolatedString(
+	node *ast.InterpolatedString,
+	env *object.Environment,
+) object.Object {
+	var out bytes.Buffer
+
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if isError(value) {
+			return value
+		}
+		if str, ok := value.(*object.String); ok {
+			out.WriteString(str.Value)
+		} else if value != nil {
+			out.WriteString(value.Inspect())
+		}
+	}
+	return &object.String{Value: out.String()}
+}
+
 // conditionals
 func evalIfExpression(
 	ie *ast.IfExpression,
@@ -411,9 +872,200 @@ func evalIfExpression(
 	}
 }
 
+// switch picks the first case whose value is == to the subject, there is
+// no fallthrough between cases
+func evalSwitchExpression(
+	se *ast.SwitchExpression,
+	env *object.Environment,
+) object.Object {
+	subject := Eval(se.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range se.Cases {
+		value := Eval(arm.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		matched := evalInfixExpression("==", subject, value)
+		if isError(matched) {
+			return matched
+		}
+		if matched == TRUE {
+			return Eval(arm.Body, env)
+		}
+	}
+
+	if se.Default != nil {
+		return Eval(se.Default, env)
+	}
+	return NULL
+}
+
+// the handler gets a scope of its own holding the caught error, like the
+// init statement of a for loop. Any expression an error value reaches
+// evaluates to that error, so the handler sees it as a hash instead, which
+// it can pass around and inspect without rethrowing it. The hash has a
+// "kind" key only for errors raised with a kind.
+func evalTryExpression(
+	te *ast.TryExpression,
+	env *object.Environment,
+) object.Object {
+	if err := checkBinding(te.Param.Value, env); err != nil {
+		return err
+	}
+	result := Eval(te.Body, env)
+	if !isError(result) {
+		return result
+	}
+
+	handlerEnv := object.NewEnclosedEnvironment(env)
+	handlerEnv.Set(te.Param.Value, caughtError(result.(*object.Error)))
+	return Eval(te.Handler, handlerEnv)
+}
+
+func caughtError(err *object.Error) *object.Hash {
+	caught := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+	set := func(name, value string) {
+		key := &object.String{Value: name}
+		caught.Set(key.HashKey(), object.HashPair{
+			Key:   key,
+			Value: &object.String{Value: value},
+		})
+	}
+	set("message", err.Message)
+	if err.Kind != "" {
+		set("kind", err.Kind)
+	}
+	return caught
+}
+
+// loops evaluate to the value of the body in the last iteration that ran
+// to its end, or null when there was none. An iteration cut short by
+// break or continue leaves the value of the one before it.
+func evalWhileExpression(
+	we *ast.WhileExpression,
+	env *object.Environment,
+) object.Object {
+	var value object.Object = NULL
+	for {
+		condition := Eval(we.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return value
+		}
+
+		result := Eval(we.Body, env)
+		if result == BREAK {
+			return value
+		}
+		if isLoopExit(result) {
+			return result
+		}
+		value = iterationValue(result, value)
+	}
+}
+
+func evalDoWhileExpression(
+	dw *ast.DoWhileExpression,
+	env *object.Environment,
+) object.Object {
+	var value object.Object = NULL
+	for {
+		result := Eval(dw.Body, env)
+		if result == BREAK {
+			return value
+		}
+		if isLoopExit(result) {
+			return result
+		}
+		value = iterationValue(result, value)
+
+		condition := Eval(dw.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return value
+		}
+	}
+}
+
+// the init statement binds into a scope of its own so loop variables do not
+// leak into the surrounding environment
+func evalForExpression(
+	fe *ast.ForExpression,
+	env *object.Environment,
+) object.Object {
+	loopEnv := object.NewEnclosedEnvironment(env)
+
+	if fe.Init != nil {
+		init := Eval(fe.Init, loopEnv)
+		if isError(init) {
+			return init
+		}
+	}
+
+	var value object.Object = NULL
+	for {
+		if fe.Condition != nil {
+			condition := Eval(fe.Condition, loopEnv)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				return value
+			}
+		}
+
+		result := Eval(fe.Body, loopEnv)
+		if result == BREAK {
+			return value
+		}
+		if isLoopExit(result) {
+			return result
+		}
+		value = iterationValue(result, value)
+
+		if fe.Post != nil {
+			post := Eval(fe.Post, loopEnv)
+			if isError(post) {
+				return post
+			}
+		}
+	}
+}
+
+// iterationValue returns the loop value after a body produced result
+func iterationValue(result, previous object.Object) object.Object {
+	if result == nil || result == CONTINUE {
+		return previous
+	}
+	return result
+}
+
+// break and continue are only valid inside a loop body
+func loopControlError(obj object.Object) *object.Error {
+	return newError("%s outside loop", obj.Inspect())
+}
+
+// isLoopExit reports whether a loop body produced a value that must
+// propagate out of the loop
+func isLoopExit(result object.Object) bool {
+	if result != nil {
+		rt := result.Type()
+		return rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ
+	}
+	return false
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
-	case NULL:
+	case NULL, UNDEFINED:
 		return false
 	case TRUE:
 		return true
@@ -455,31 +1107,55 @@ func evalIdentifier(
 }
 
 // function call
+
+// evalExpressions evaluates exps from left to right, stopping at the first
+// one that fails and returning its error.
 func evalExpressions(
 	exps []ast.Expression,
 	env *object.Environment,
-) []object.Object {
+) ([]object.Object, *object.Error) {
 	var args []object.Object
 	for _, exp := range exps {
 		evaluated := Eval(exp, env)
-		if isError(evaluated) {
-			return []object.Object{evaluated}
+		if err, ok := evaluated.(*object.Error); ok {
+			return nil, err
 		}
 		args = append(args, evaluated)
 	}
-	return args
+	return args, nil
 }
 
+// applyFunction calls fn with args, env is the environment of the call site
 func applyFunction(
 	fn object.Object,
 	args []object.Object,
+	env *object.Environment,
 ) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError(
+				"wrong number of arguments: got=%d, want=%d",
+				len(args),
+				len(fn.Parameters),
+			)
+		}
+		state := attachState(env)
+		if err := state.enterCall(); err != nil {
+			return err
+		}
+		defer state.leaveCall()
+
 		extendedEnv := extendFunction(fn, args)
 		evaluated := Eval(fn.Body, extendedEnv)
+		if evaluated == BREAK || evaluated == CONTINUE {
+			return loopControlError(evaluated)
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
+		if fn.EnvFn != nil {
+			return fn.EnvFn(env, args...)
+		}
 		return fn.Fn(args...)
 	default:
 		return newError("not a function: %s", fn.Type())
@@ -513,7 +1189,7 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	maxIdx := int64(len(arrayObject.Elements) - 1)
 
 	if idx < 0 || idx > maxIdx {
-		return NULL
+		return UNDEFINED
 	}
 	return arrayObject.Elements[idx]
 }
@@ -521,14 +1197,14 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
+	key, ok := object.HashKeyOf(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Pairs[key]
 	if !ok {
-		return NULL
+		return UNDEFINED
 	}
 	return pair.Value
 }
@@ -552,15 +1228,18 @@ func evalHashLiteral(
 	node *ast.HashLiteral,
 	env *object.Environment,
 ) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := &object.Hash{
+		Pairs: make(map[object.HashKey]object.HashPair, len(node.Pairs)),
+	}
 
-	for nodeKey, nodeValue := range node.Pairs {
+	for _, nodeKey := range node.OrderedKeys() {
+		nodeValue := node.Pairs[nodeKey]
 		key := Eval(nodeKey, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.HashKeyOf(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
@@ -570,13 +1249,10 @@ func evalHashLiteral(
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{
+		hash.Set(hashKey, object.HashPair{
 			Key:   key,
 			Value: value,
-		}
-	}
-	return &object.Hash{
-		Pairs: pairs,
+		})
 	}
+	return hash
 }
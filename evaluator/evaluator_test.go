@@ -1,8 +1,10 @@
 package evaluator
 
 import (
+	"math"
 	"testing"
 
+	"github.com/anukuljoshi/monkey/ast"
 	"github.com/anukuljoshi/monkey/lexer"
 	"github.com/anukuljoshi/monkey/object"
 	"github.com/anukuljoshi/monkey/parser"
@@ -40,6 +42,10 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"10", 10},
 		{"-5", -5},
 		{"-10", -10},
+		{"+5", 5},
+		{"+(-5)", -5},
+		{"-(+5)", -5},
+		{"3 - +2", 1},
 		{"5 + 5 + 5 + 5 - 10", 10},
 		{"2 * 2 * 2 * 2 * 2", 32},
 		{"-50 + 100 + -50", 0},
@@ -104,6 +110,22 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{`"foo" != "bar"`, true},
 		{`"aa" > "bb"`, false},
 		{`"aa" < "bb"`, true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"99999999999999999999 >= 99999999999999999999", true},
+		{"99999999999999999999 <= 1", false},
+		{"1.5 <= 1.5", true},
+		{"1 >= 1.5", false},
+		{`"aa" <= "bb"`, true},
+		{`"bb" <= "bb"`, true},
+		{`"bc" <= "bb"`, false},
+		{`"aa" >= "bb"`, false},
+		{`"bb" >= "bb"`, true},
+		{`"b" >= "abc"`, true},
+		{`"" <= "a"`, true},
 	}
 
 	for _, tt := range tests {
@@ -126,6 +148,13 @@ func TestBangOperator(t *testing.T) {
 		{"!!true", true},
 		{"!!false", false},
 		{"!!5", true},
+		{"not true", false},
+		{"not false", true},
+		{"not 5", false},
+		{"not not true", true},
+		{"not not false", false},
+		{"not !true", true},
+		{"not (1 > 2)", true},
 	}
 
 	for _, tt := range tests {
@@ -134,6 +163,51 @@ func TestBangOperator(t *testing.T) {
 	}
 }
 
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"true and true", true},
+		{"true and false", false},
+		{"false or true", true},
+		{"false or false", false},
+		{"1 && \"a\"", true},
+		{"if (false) { 1 } || 0", true},
+		{"1 < 2 and 2 < 3", true},
+		{"1 > 2 or 2 > 3", false},
+		{"not false and true", true},
+		// short-circuiting skips the right operand entirely
+		{"false && missing", false},
+		{"false and missing", false},
+		{"true || missing", true},
+		{"true or missing", true},
+		{"let n = 0; false and (n = 1); n", 0},
+		{"let n = 0; true or (n = 1); n", 0},
+		{"let n = 0; true and (n = 1); n", 1},
+		{"let n = 0; false or (n = 1); n", 1},
+		{"true && missing", "identifier not found: missing"},
+		{"false or missing", "identifier not found: missing"},
+		{"missing or true", "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
 // conditionals
 func TestIfElseExpressions(t *testing.T) {
 	tests := []struct {
@@ -147,6 +221,19 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (2 < 1) { 20 } else { 30 }", 30},
+		{"if (1 < 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 10},
+		{"if (false) { 10 } else if (false) { 20 }", nil},
+		{
+			`let grade = fn(n) {
+				if (n >= 90) { 1 } else if (n >= 80) { 2 } else if (n >= 70) { 3 } else { 4 }
+			};
+			grade(95) * 1000 + grade(85) * 100 + grade(75) * 10 + grade(5)`,
+			1234,
+		},
+		// later conditions are not evaluated once a branch is taken
+		{"if (true) { 10 } else if (missing) { 20 }", 10},
 	}
 
 	for _, tt := range tests {
@@ -168,6 +255,14 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 	return true
 }
 
+func testUndefinedObject(t *testing.T, obj object.Object) bool {
+	if obj != UNDEFINED {
+		t.Errorf("object is not UNDEFINED, got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
+
 // return statements
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
@@ -197,6 +292,26 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestBareReturn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn() { return; 5 }; f()", "null"},
+		{"let f = fn() { return }; f()", "null"},
+		{"let f = fn(x) { if (x > 0) { return } x }; [f(1), f(-1)]", "[null, -1]"},
+		{"let n = 0; let f = fn() { n = 1; return; n = 2 }; f(); n", "1"},
+		{"return; 5", "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 // error handling
 func TestErrorHandling(t *testing.T) {
 	tests := []struct {
@@ -211,10 +326,26 @@ func TestErrorHandling(t *testing.T) {
 			"5 + true; 5;",
 			"type mismatch: INTEGER + BOOLEAN",
 		},
+		{
+			"5 / 0;",
+			"division by zero",
+		},
+		{
+			"let zero = 0; -9223372036854775807 / zero;",
+			"division by zero",
+		},
 		{
 			"-true;",
 			"unknown operator: -BOOLEAN",
 		},
+		{
+			"+true;",
+			"unknown operator: +BOOLEAN",
+		},
+		{
+			`+"5";`,
+			"unknown operator: +STRING",
+		},
 		{
 			"true + false;",
 			"unknown operator: BOOLEAN + BOOLEAN",
@@ -286,6 +417,80 @@ func TestLeftStatements(t *testing.T) {
 	}
 }
 
+func TestLetStatementValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"if (true) { let y = 7; }", 7},
+		{"let f = fn() { let z = 3; }; f()", 3},
+		{`let f = fn() { if (true) { let s = 4 } }; f()`, 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestProgramValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1; 2 * 3", "6"},
+		{"let x = 5; x", "5"},
+		{"let f = fn() { 1 }; f()", "1"},
+		// a program ending in a binding has nothing to show
+		{"let x = 5;", "null"},
+		{"let x = 5", "null"},
+		{"let a = 1; let b = a + 1;", "null"},
+		{"let a = 1; a = 2", "null"},
+		{"let a = 1; a = 2;", "null"},
+		{"let a = [1]; a[0] = 2", "null"},
+		// a return ends the program with its value
+		{"return 4; let x = 5;", "4"},
+		{"let x = 5; return x;", "5"},
+		{"let x = 5; return;", "null"},
+		{"", "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestIsBinding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 5;", true},
+		{"x = 6", true},
+		{"a[0] = 1", true},
+		{`import "lib"`, true},
+		{"x", false},
+		{"x + 1", false},
+		{"x++", false},
+		{"let f = fn() { x = 1 }; f()", false},
+		{"x; let y = x;", true},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%s: parser errors: %q", tt.input, p.Errors())
+		}
+		last := program.Statements[len(program.Statements)-1]
+		if got := IsBinding(last); got != tt.expected {
+			t.Errorf("IsBinding(%q): expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
+
 // functions
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2 };"
@@ -332,6 +537,84 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(x, y) { x + y; }; add(1);", "wrong number of arguments: got=1, want=2"},
+		{"let add = fn(x, y) { x + y; }; add(1, 2, 3);", "wrong number of arguments: got=3, want=2"},
+		{"fn() { 1 }(1)", "wrong number of arguments: got=1, want=0"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestNamedFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fn fib(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }(10)", 55},
+		{"let fact = fn f(n) { if (n < 2) { 1 } else { n * f(n - 1) } }; fact(5)", 120},
+		{"let count = fn down(n) { if (n == 0) { 0 } else { down(n - 1) } }; count(3)", 0},
+		{
+			`let makeCounter = fn() { fn loop(n, acc) { if (n == 0) { acc } else { loop(n - 1, acc + n) } } };
+			makeCounter()(4, 0)`,
+			10,
+		},
+		{"let f = fn g(x) { x }; g", "identifier not found: g"},
+		{"let g = 1; let f = fn g(x) { g }; f(2) == f; g", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestHoistedFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+			let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+			[isEven(10), isOdd(7), isEven(3)]`,
+			"[true, true, false]",
+		},
+		{
+			`let result = isEven(4);
+			let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+			let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+			result`,
+			"true",
+		},
+		{"let x = double(4); let double = fn(n) { n * 2 }; x", "8"},
+		{"let f = g(); let g = fn() { 1 }; let g = fn() { 2 }; [f, g()]", "[2, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("let x = later; let later = 5; x"), "identifier not found: later")
+	// only the top level is hoisted, inside a function lets run in order
+	testErrorObject(t,
+		testEval("let f = fn() { let x = g(); let g = fn() { 1 }; x }; f()"),
+		"identifier not found: g")
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 	let newAdder = fn(x) {
@@ -388,245 +671,1889 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
-// builtin functions
-func TestBuiltinFunctions(t *testing.T) {
+func TestStringRepetition(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected interface{}
 	}{
-		// len
-		{`len("")`, 0},
-		{`len("four")`, 4},
-		{`len([1,2,3,4,true,"abcd"])`, 6},
-		{`len([])`, 0},
-		{`len("hello", "world")`, "wrong number of arguments: got=2, want=1"},
-		{`len(1)`, "argument to `len` not supported, got=INTEGER"},
-		// first
-		{`first([1,2,3,4])`, 1},
-		{`first(["abcd"])`, "abcd"},
-		{`first([])`, nil},
-		{`first("hello", "world")`, "wrong number of arguments: got=2, want=1"},
-		{`first(1)`, "argument to `first` must be ARRAY, got=INTEGER"},
-		// last
-		{`last([1,2,3,4])`, 4},
-		{`last(["abcd"])`, "abcd"},
-		{`last([])`, nil},
-		{`last("hello", "world")`, "wrong number of arguments: got=2, want=1"},
-		{`last(1)`, "argument to `last` must be ARRAY, got=INTEGER"},
-		// rest
-		{`rest([1,2,3,4])`, []int{2, 3, 4}},
-		{`rest([])`, nil},
-		{`rest("hello", "world")`, "wrong number of arguments: got=2, want=1"},
-		{`rest(1)`, "argument to `rest` must be ARRAY, got=INTEGER"},
-		// push
-		{`push([1,2,3,4], 5)`, []int{1, 2, 3, 4, 5}},
-		{`push([], 2)`, []int{2}},
-		{`push(1)`, "wrong number of arguments: got=1, want=2"},
-		{`push("hello", "world")`, "argument to `push` must be ARRAY, got=STRING"},
-		{`push(1, 1)`, "argument to `push` must be ARRAY, got=INTEGER"},
+		{`"ab" * 3`, "ababab"},
+		{`3 * "ab"`, "ababab"},
+		{`"ab" * 1`, "ab"},
+		{`"ab" * 0`, ""},
+		{`0 * "ab"`, ""},
+		{`"" * 5`, ""},
+		{`"-" * 2 + ">"`, "-->"},
+		{`"ab" * -1`, "negative repetition count: -1"},
+		{`-2 * "ab"`, "negative repetition count: -2"},
+		{`"ab" * 9223372036854775807`, "repetition result too large: 2 * 9223372036854775807"},
+		{`"ab" * 33554433`, "repetition result too large: 2 * 33554433"},
+		{`"" * 9223372036854775807`, ""},
+		{`"ab" - 2`, "type mismatch: STRING - INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Error); ok {
+			testErrorObject(t, evaluated, tt.expected.(string))
+			continue
+		}
+		testStringObject(t, evaluated, tt.expected.(string))
+	}
+}
+
+func TestAssert(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"assert(true)", nil},
+		{"assert(1 + 1 == 2)", nil},
+		{"assert(0)", nil},
+		{`assert(true, "never shown")`, nil},
+		{"assert(false)", "assertion failed"},
+		{"assert(if (false) { 1 })", "assertion failed"},
+		{"let x = 4; assert(x == 5)", "assertion failed"},
+		{`let x = 4; assert(x == 5, "x should be 5")`, "assertion failed: x should be 5"},
+		{"assert(false, [1, 2])", "assertion failed: [1, 2]"},
+		{`assert(false); "not reached"`, "assertion failed"},
+		{"assert()", "wrong number of arguments: got=0, want=1 or 2"},
+		{`assert(true, "a", "b")`, "wrong number of arguments: got=3, want=1 or 2"},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
 		switch expected := tt.expected.(type) {
-		case int:
-			testIntegerObject(t, evaluated, int64(expected))
 		case nil:
 			testNullObject(t, evaluated)
 		case string:
-			switch obj := evaluated.(type) {
-			case *object.Error:
-				if obj.Message != expected {
-					t.Errorf("wrong error message: expected=%q, got=%q",
-						expected, obj.Message)
-				}
-			case *object.String:
-				if obj.Value != tt.expected {
-					t.Errorf("obj.Value: expected=%s, got=%s",
-						tt.expected, obj.Value)
-				}
-			default:
-				t.Errorf("obj.Value type not expected, got=%T",
-					obj)
-			}
-		case []int:
-			array, ok := evaluated.(*object.Array)
-			if !ok {
-				t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
-				continue
-			}
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
 
-			if len(array.Elements) != len(expected) {
-				t.Errorf("wrong num of elements. want=%d, got=%d",
-					len(expected), len(array.Elements))
+func TestTypePredicates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"isInt(1)", true},
+		{"isInt(9223372036854775807 + 1)", true},
+		{`isInt("1")`, false},
+		{`isString("a")`, true},
+		{"isString(1)", false},
+		{"isArray([])", true},
+		{"isArray({})", false},
+		{"isHash({})", true},
+		{"isHash([])", false},
+		{"isFunction(fn(x) { x })", true},
+		{"isFunction(len)", true},
+		{"isFunction(1)", false},
+		{"isNull(if (false) { 1 })", true},
+		{"isNull(0)", false},
+		{"isNull(false)", false},
+		{"isInt()", "wrong number of arguments: got=0, want=1"},
+		{"isNull(1, 2)", "wrong number of arguments: got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestFloatExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1.5", 1.5},
+		{"-2.5", -2.5},
+		{"1.5 + 2.25", 3.75},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"7 / 2.0", 3.5},
+		{"1.0 - 3", -2.0},
+		{"1.5 > 1", true},
+		{"2 < 1.5", false},
+		{"2 == 2.0", true},
+		{"0.1 + 0.2 != 0.3", true},
+		{"1.5 in [1, 1.5]", true},
+		{"1.5e3", 1500.0},
+		{"2E-2", 0.02},
+		{"1e2 + 1", 101.0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case float64:
+			f, ok := evaluated.(*object.Float)
+			if !ok {
+				t.Errorf("%s: object is not Float. got=%T (%+v)", tt.input, evaluated, evaluated)
 				continue
 			}
-
-			for i, expectedElem := range expected {
-				testIntegerObject(t, array.Elements[i], int64(expectedElem))
+			if f.Value != expected {
+				t.Errorf("%s: expected=%g, got=%g", tt.input, expected, f.Value)
 			}
-		default:
-			t.Errorf("tt.expected type not expected, got=%T",
-				tt.expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
 		}
 	}
 }
 
-// array literal
-func TestArrayLiterals(t *testing.T) {
-	input := "[1, 2 * 2, 3 + 3]"
-	evaluated := testEval(input)
-	result, ok := evaluated.(*object.Array)
-	if !ok {
-		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+func TestRoundingBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"round(3.6)", 4},
+		{"round(3.4)", 3},
+		{"round(2.5)", 3},
+		{"round(-2.5)", -3},
+		{"round(0.5)", 1},
+		{"round(-0.5)", -1},
+		{"floor(3.9)", 3},
+		{"floor(3.5)", 3},
+		{"floor(-3.5)", -4},
+		{"ceil(3.1)", 4},
+		{"ceil(3.5)", 4},
+		{"ceil(-3.5)", -3},
+		{"round(7)", 7},
+		{"floor(-7)", -7},
+		{"ceil(0)", 0},
+		{`round("1.5")`, "argument to `round` must be INTEGER or FLOAT, got=STRING"},
+		{"floor([])", "argument to `floor` must be INTEGER or FLOAT, got=ARRAY"},
+		{"ceil(1.5, 2)", "wrong number of arguments: got=2, want=1"},
 	}
-	if len(result.Elements) != 3 {
-		t.Fatalf("len(result.Elements): expected=%d, got=%d",
-			3, len(result.Elements))
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+
+	// results beyond int64 become big integers
+	evaluated := testEval("round(100000000000000000000.4)")
+	if evaluated.Inspect() != "100000000000000000000" {
+		t.Errorf("round of a large float: expected=%s, got=%s",
+			"100000000000000000000", evaluated.Inspect())
 	}
-	testIntegerObject(t, result.Elements[0], 1)
-	testIntegerObject(t, result.Elements[1], 4)
-	testIntegerObject(t, result.Elements[2], 6)
 }
 
-func TestArrayIndexExpressions(t *testing.T) {
+func TestMathBuiltins(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected interface{}
 	}{
-		{
+		{"sqrt(16)", 4.0},
+		{"sqrt(2.25)", 1.5},
+		{"sqrt(0)", 0.0},
+		{"pow(2, 10)", 1024},
+		{"pow(5, 0)", 1},
+		{"pow(-3, 3)", -27},
+		{"pow(1, 100000000000)", 1},
+		{"pow(-1, 100000000001)", -1},
+		{"pow(0, 100000000000)", 0},
+		{"pow(2, -1)", 0.5},
+		{"pow(2.5, 2)", 6.25},
+		{"pow(4, 0.5)", 2.0},
+		{"pi()", math.Pi},
+		{"sqrt(-4)", "square root of negative number: -4"},
+		{"sqrt(-0.25)", "square root of negative number: -0.25"},
+		{`sqrt("16")`, "argument to `sqrt` must be INTEGER or FLOAT, got=STRING"},
+		{`pow(2, "a")`, "arguments to `pow` must be INTEGER or FLOAT, got=INTEGER, STRING"},
+		{"pow(2)", "wrong number of arguments: got=1, want=2"},
+		{"pow(10, 100000000000)", "result of `pow` is too large"},
+		{"pow(-2, 9223372036854775807)", "result of `pow` is too large"},
+		{"pi(1)", "wrong number of arguments: got=1, want=0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			f, ok := evaluated.(*object.Float)
+			if !ok {
+				t.Errorf("%s: object is not Float. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if f.Value != expected {
+				t.Errorf("%s: expected=%g, got=%g", tt.input, expected, f.Value)
+			}
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+
+	evaluated := testEval("pow(2, 100)")
+	if evaluated.Inspect() != "1267650600228229401496703205376" {
+		t.Errorf("pow(2, 100): expected a big integer, got=%s", evaluated.Inspect())
+	}
+}
+
+func TestRandomBuiltins(t *testing.T) {
+	input := `seed(42); [rand(), rand(), randInt(1, 6), randInt(-100, 100)]`
+	first := testEval(input).Inspect()
+	second := testEval(input).Inspect()
+	if first != second {
+		t.Errorf("seeded sequences differ: %s and %s", first, second)
+	}
+
+	other := testEval(`seed(7); [rand(), rand(), randInt(1, 6), randInt(-100, 100)]`)
+	if other.Inspect() == first {
+		t.Errorf("different seeds produced the same sequence: %s", first)
+	}
+
+	// seeding from a nested scope seeds the whole evaluation
+	for _, nested := range []string{
+		"for (let i = 0; i < 1; i++) { seed(42) }",
+		`try { 1 + "a" } catch (e) { seed(42) }`,
+		"let f = fn() { seed(42) }; f()",
+	} {
+		seeded := testEval(nested + "; [rand(), rand(), randInt(1, 6), randInt(-100, 100)]")
+		if seeded.Inspect() != first {
+			t.Errorf("%s: expected=%s, got=%s", nested, first, seeded.Inspect())
+		}
+	}
+
+	rolls := testEval(`
+let rolls = [];
+for (let i = 0; i < 200; i++) { rolls = push(rolls, randInt(1, 6)); }
+rolls`)
+	for _, roll := range rolls.(*object.Array).Elements {
+		value := roll.(*object.Integer).Value
+		if value < 1 || value > 6 {
+			t.Fatalf("randInt(1, 6) out of range: %d", value)
+		}
+	}
+
+	samples := testEval(`
+let samples = [];
+for (let i = 0; i < 200; i++) { samples = push(samples, rand()); }
+samples`)
+	for _, sample := range samples.(*object.Array).Elements {
+		value := sample.(*object.Float).Value
+		if value < 0 || value >= 1 {
+			t.Fatalf("rand() out of range: %g", value)
+		}
+	}
+
+	testIntegerObject(t, testEval("randInt(3, 3)"), 3)
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"randInt(6, 1)", "invalid range for `randInt`: 6 > 1"},
+		{"randInt(1.5, 2)", "arguments to `randInt` must be INTEGER, got=FLOAT, INTEGER"},
+		{"randInt(-9223372036854775807 - 1, 9223372036854775807)", "range for `randInt` is too large"},
+		{`seed("a")`, "argument to `seed` must be INTEGER, got=STRING"},
+		{"rand(1)", "wrong number of arguments: got=1, want=0"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"count([1, 2, 2, 3, 2], 2)", 3},
+		{"count([1, 2, 3], 4)", 0},
+		{"count([], 1)", 0},
+		{`count(["a", "b", "a"], "a")`, 2},
+		{`count([1, "1", true], 1)`, 1},
+		{"count([1, 1.0, 2], 1)", 2},
+		{`count("banana", "a")`, 3},
+		{`count("banana", "an")`, 2},
+		{`count("banana", "x")`, 0},
+		{`count("", "a")`, 0},
+		{`count("banana", 1)`, "second argument to `count` must be STRING, got=INTEGER"},
+		{"count(1, 1)", "argument to `count` not supported, got=INTEGER"},
+		{"count([1])", "wrong number of arguments: got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestZip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`zip([1, 2, 3], ["a", "b", "c"])`, "[[1, a], [2, b], [3, c]]"},
+		{`zip([1, 2, 3], ["a"])`, "[[1, a]]"},
+		{`zip([1], [true, false])`, "[[1, true]]"},
+		{"zip([], [1, 2])", "[]"},
+		{"zip([], [])", "[]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Array); !ok {
+			t.Errorf("%s: object is not Array. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`zip([1], "a")`),
+		"arguments to `zip` must be ARRAY, got=ARRAY, STRING")
+	testErrorObject(t, testEval("zip([1])"),
+		"wrong number of arguments: got=1, want=2")
+}
+
+func TestEnumerate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{"enumerate([[1], 2])", "[[0, [1]], [1, 2]]"},
+		{"enumerate([])", "[]"},
+		{`let pair = enumerate(["x", "y", "z"])[2]; pair[0]`, "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`enumerate("ab")`),
+		"argument to `enumerate` must be ARRAY, got=STRING")
+	testErrorObject(t, testEval("enumerate([], [])"),
+		"wrong number of arguments: got=2, want=1")
+}
+
+func TestUnique(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"unique([1, 2, 2, 3, 1])", "[1, 2, 3]"},
+		{"unique([3, 2, 1])", "[3, 2, 1]"},
+		{"unique([])", "[]"},
+		{`unique([1, "1", true, 1, "1", true])`, "[1, 1, true]"},
+		{"unique([1, 1.0, 2.5, 2.5, 2])", "[1, 2.5, 2]"},
+		{"unique([1.0, 1])", "[1.0]"},
+		{"let a = [1]; unique([a, a, [1]])", "[[1], [1]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Array); !ok {
+			t.Errorf("%s: object is not Array. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("unique(1)"),
+		"argument to `unique` must be ARRAY, got=INTEGER")
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"flatten([[1, 2], [3, [4]]])", "[1, 2, 3, [4]]"},
+		{"flatten([[1, 2], [3, [4]]], 1)", "[1, 2, 3, [4]]"},
+		{"flatten([[1, [2, [3, [4]]]]], 2)", "[1, 2, [3, [4]]]"},
+		{"flatten([[1, [2, [3, [4]]]]], -1)", "[1, 2, 3, 4]"},
+		{"flatten([[1], [2]], 0)", "[[1], [2]]"},
+		{`flatten([1, "a", [true, {}], []])`, "[1, a, true, {}]"},
+		{"flatten([])", "[]"},
+		{"flatten([[], [[]]], -1)", "[]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Array); !ok {
+			t.Errorf("%s: object is not Array. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"flatten(1)", "argument to `flatten` must be ARRAY, got=INTEGER"},
+		{`flatten([], "a")`, "depth for `flatten` must be INTEGER, got=STRING"},
+		{"flatten([], -2)", "invalid depth for `flatten`: -2"},
+		{"flatten()", "wrong number of arguments: got=0, want=1 or 2"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTakeAndDrop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"take([1, 2, 3, 4], 2)", "[1, 2]"},
+		{"take([1, 2, 3, 4], 0)", "[]"},
+		{"take([1, 2, 3, 4], 10)", "[1, 2, 3, 4]"},
+		{"take([1, 2, 3, 4], -1)", "[4]"},
+		{"take([1, 2, 3, 4], -10)", "[1, 2, 3, 4]"},
+		{"take([], 3)", "[]"},
+		{"drop([1, 2, 3, 4], 2)", "[3, 4]"},
+		{"drop([1, 2, 3, 4], 0)", "[1, 2, 3, 4]"},
+		{"drop([1, 2, 3, 4], 10)", "[]"},
+		{"drop([1, 2, 3, 4], -1)", "[1, 2, 3]"},
+		{"drop([1, 2, 3, 4], -10)", "[]"},
+		{"drop([], -1)", "[]"},
+		{"let xs = [1, 2, 3]; take(xs, 1); xs", "[1, 2, 3]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Array); !ok {
+			t.Errorf("%s: object is not Array. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`take("abc", 1)`, "arguments to `take` must be ARRAY, INTEGER, got=STRING, INTEGER"},
+		{"drop([1], 1.5)", "arguments to `drop` must be ARRAY, INTEGER, got=ARRAY, FLOAT"},
+		{"take([1])", "wrong number of arguments: got=1, want=2"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// builtin functions
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// len
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len([1,2,3,4,true,"abcd"])`, 6},
+		{`len([])`, 0},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`len({})`, 0},
+		{`len({"a": 1, "a": 2})`, 1},
+		{`len("hello", "world")`, "wrong number of arguments: got=2, want=1"},
+		{`len(1)`, "argument to `len` not supported, got=INTEGER"},
+		// first
+		{`first([1,2,3,4])`, 1},
+		{`first(["abcd"])`, "abcd"},
+		{`first([])`, nil},
+		{`first("hello", "world")`, "wrong number of arguments: got=2, want=1"},
+		{`first(1)`, "argument to `first` must be ARRAY, got=INTEGER"},
+		// last
+		{`last([1,2,3,4])`, 4},
+		{`last(["abcd"])`, "abcd"},
+		{`last([])`, nil},
+		{`last("hello", "world")`, "wrong number of arguments: got=2, want=1"},
+		{`last(1)`, "argument to `last` must be ARRAY, got=INTEGER"},
+		// rest
+		{`rest([1,2,3,4])`, []int{2, 3, 4}},
+		{`rest([])`, nil},
+		{`rest("hello", "world")`, "wrong number of arguments: got=2, want=1"},
+		{`rest(1)`, "argument to `rest` must be ARRAY, got=INTEGER"},
+		// push
+		{`push([1,2,3,4], 5)`, []int{1, 2, 3, 4, 5}},
+		{`push([], 2)`, []int{2}},
+		{`push(1)`, "wrong number of arguments: got=1, want=2"},
+		{`push("hello", "world")`, "argument to `push` must be ARRAY, got=STRING"},
+		{`push(1, 1)`, "argument to `push` must be ARRAY, got=INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message: expected=%q, got=%q",
+						expected, obj.Message)
+				}
+			case *object.String:
+				if obj.Value != tt.expected {
+					t.Errorf("obj.Value: expected=%s, got=%s",
+						tt.expected, obj.Value)
+				}
+			default:
+				t.Errorf("obj.Value type not expected, got=%T",
+					obj)
+			}
+		case []int:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d",
+					len(expected), len(array.Elements))
+				continue
+			}
+
+			for i, expectedElem := range expected {
+				testIntegerObject(t, array.Elements[i], int64(expectedElem))
+			}
+		default:
+			t.Errorf("tt.expected type not expected, got=%T",
+				tt.expected)
+		}
+	}
+}
+
+// array literal
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(result.Elements) != 3 {
+		t.Fatalf("len(result.Elements): expected=%d, got=%d",
+			3, len(result.Elements))
+	}
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestExpressionListErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, missing, 3]", "identifier not found: missing"},
+		{"[1, 2 + true]", "type mismatch: INTEGER + BOOLEAN"},
+		{"[1, missing, 2 + true]", "identifier not found: missing"},
+		{"let f = fn(a, b) { a }; f(1, missing)", "identifier not found: missing"},
+		{"len(1, missing)", "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrayOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2] + [3]", "[1, 2, 3]"},
+		{"[] + [1]", "[1]"},
+		{"[1] + []", "[1]"},
+		{"[] + []", "[]"},
+		{"[0] * 3", "[0, 0, 0]"},
+		{"2 * [1, 2]", "[1, 2, 1, 2]"},
+		{"[1, 2] * 0", "[]"},
+		{"[] * 9223372036854775807", "[]"},
+		{"[[1]] * 2", "[[1], [1]]"},
+		{"let a = [1]; let b = a + [2]; a", "[1]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		arr, ok := evaluated.(*object.Array)
+		if !ok {
+			t.Errorf("%s: object is not Array. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if arr.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, arr.Inspect())
+		}
+	}
+
+	// nested values are shared, not copied
+	evaluated := testEval("let inner = [1]; let outer = [inner] * 2; outer")
+	arr := evaluated.(*object.Array)
+	if arr.Elements[0] != arr.Elements[1] {
+		t.Errorf("repeated elements should be the same object")
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1] + 2", "type mismatch: ARRAY + INTEGER"},
+		{"2 + [1]", "type mismatch: INTEGER + ARRAY"},
+		{"[1] - [1]", "unknown operator: ARRAY - ARRAY"},
+		{"[1] * [1]", "unknown operator: ARRAY * ARRAY"},
+		{"[1] * -1", "negative repetition count: -1"},
+		{"[1] * 4611686018427387904", "repetition result too large: 1 * 4611686018427387904"},
+		{"[1, 2] * 33554433", "repetition result too large: 2 * 33554433"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
 			"[1, 2, 3][0]",
 			1,
 		},
 		{
-			"[1, 2, 3][1]",
-			2,
+			"[1, 2, 3][1]",
+			2,
+		},
+		{
+			"[1, 2, 3][2]",
+			3,
+		},
+		{
+			"let i = 0; [1][i];",
+			1,
+		},
+		{
+			"[1, 2, 3][1 + 1];",
+			3,
+		},
+		{
+			"let myArray = [1, 2, 3]; myArray[2];",
+			3,
+		},
+		{
+			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
+			6,
+		},
+		{
+			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
+			2,
+		},
+		{
+			"[1, 2, 3][3]",
+			nil,
+		},
+		{
+			"[1, 2, 3][-1]",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testUndefinedObject(t, evaluated)
+		}
+	}
+}
+
+// hash map
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("evaluated is not *object.Hash, got=%T (%+v)",
+			evaluated, evaluated)
+	}
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("len(result.Pairs): expected=%d, got=%d",
+			len(expected), len(result.Pairs))
+	}
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Fatalf("no value for given key in Pairs")
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+}
+
+func TestHashLiteralWithoutKeys(t *testing.T) {
+	// a literal built by hand may fill in Pairs only
+	key := &ast.StringLiteral{Value: "a"}
+	node := &ast.HashLiteral{
+		Pairs: map[ast.Expression]ast.Expression{
+			key: &ast.IntegerLiteral{Value: 1},
+		},
+	}
+
+	evaluated := Eval(node, object.NewEnvironment())
+	if evaluated.Inspect() != "{a: 1}" {
+		t.Errorf("expected=%s, got=%s", "{a: 1}", evaluated.Inspect())
+	}
+}
+
+// access hash map by keys
+func TestInOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"2 in [1, 2, 3]", true},
+		{"4 in [1, 2, 3]", false},
+		{"1 in []", false},
+		{`"b" in ["a", "b"]`, true},
+		{`"1" in [1]`, false},
+		{"true in [false, true]", true},
+		{"let xs = [1, 2]; 1 + 1 in xs", true},
+		{`"a" in {"a": 1}`, true},
+		{`"b" in {"a": 1}`, false},
+		{`1 in {1: "one", true: "yes"}`, true},
+		{`true in {1: "one"}`, false},
+		{"2 in 5", "operator in not supported: INTEGER in INTEGER"},
+		{`"a" in "abc"`, "operator in not supported: STRING in STRING"},
+		{`[1] in {"a": 1}`, false},
+		{`[1, "a"] in {[1, "a"]: 1}`, true},
+		{`[{}] in {"a": 1}`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, "{z: 1, a: 2, m: 3}"},
+		{`keys({"z": 1, "a": 2, "m": 3})`, "[z, a, m]"},
+		{`values({"z": 1, "a": 2, "m": 3})`, "[1, 2, 3]"},
+		{`{3: "c", true: "t", "1": "s", 1: "i"}`, "{3: c, true: t, 1: s, 1: i}"},
+		{`keys({"a": 1, "b": 2, "a": 3})`, "[a, b]"},
+		{`values({"a": 1, "b": 2, "a": 3})`, "[3, 2]"},
+		{"keys({})", "[]"},
+		{"values({})", "[]"},
+		{`pairs({"a": 1, "b": 2})`, "[[a, 1], [b, 2]]"},
+		{`pairs({3: "c", true: "t", "1": "s", [1, 2]: "a"})`, "[[3, c], [true, t], [1, s], [[1, 2], a]]"},
+		{`pairs({"a": 1, "b": 2, "a": 3})`, "[[a, 3], [b, 2]]"},
+		{`pairs(merge({"z": 1}, {"a": 2}))`, "[[z, 1], [a, 2]]"},
+		{`pairs({"a": 1})[0][1]`, "1"},
+		{"pairs({})", "[]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("keys([1])"), "argument to `keys` must be HASH, got=ARRAY")
+	testErrorObject(t, testEval("values(1)"), "argument to `values` must be HASH, got=INTEGER")
+	testErrorObject(t, testEval("pairs([[1, 2]])"), "argument to `pairs` must be HASH, got=ARRAY")
+	testErrorObject(t, testEval("pairs({}, {})"), "wrong number of arguments: got=2, want=1")
+}
+
+func TestUndefined(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {"stored": if (false) { 1 }}; h["stored"]`, "null"},
+		{`let h = {"stored": if (false) { 1 }}; h["missing"]`, "undefined"},
+		{"[if (false) { 1 }][0]", "null"},
+		{"[][0]", "undefined"},
+		{"fromJSON(`{\"a\": null}`)[\"a\"]", "null"},
+		{"fromJSON(`{\"a\": null}`)[\"b\"]", "undefined"},
+		{`{"a": 1}["b"] == {"a": 1}["c"]`, true},
+		{`{"a": 1}["b"] == if (false) { 1 }`, false},
+		{`if ({}["x"]) { 1 } else { 2 }`, 2},
+		{"![][0]", true},
+		{"not [1][5]", true},
+		{"[][0] or false", false},
+		{`isNull({}["x"])`, false},
+		{"toJSON([[][0]])", "[null]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("%s: expected=%s, got=%s", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestPop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"pop([1, 2, 3])", "[3, [1, 2]]"},
+		{"pop([1])", "[1, []]"},
+		{"pop([1, 2, 3])[0]", "3"},
+		{"pop(pop([1, 2, 3])[1])", "[2, [1]]"},
+		{"let a = [1, 2]; pop(a); a", "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("pop([])"), "cannot pop from an empty array")
+	testErrorObject(t, testEval(`pop("abc")`), "argument to `pop` must be ARRAY, got=STRING")
+	testErrorObject(t, testEval("pop([1], [2])"), "wrong number of arguments: got=2, want=1")
+}
+
+func TestInsertAndRemoveAt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"insert([1, 3], 1, 2)", "[1, 2, 3]"},
+		{"insert([1, 2], 0, 0)", "[0, 1, 2]"},
+		{"insert([1, 2], 2, 3)", "[1, 2, 3]"},
+		{"insert([1, 2], 10, 3)", "[1, 2, 3]"},
+		{"insert([1, 2], -4, 0)", "[0, 1, 2]"},
+		{"insert([], 0, 1)", "[1]"},
+		{"let a = [1, 3]; insert(a, 1, 2); a", "[1, 3]"},
+		{"removeAt([1, 2, 3], 1)", "[1, 3]"},
+		{"removeAt([1, 2, 3], 0)", "[2, 3]"},
+		{"removeAt([1, 2, 3], 2)", "[1, 2]"},
+		{"removeAt([1], 0)", "[]"},
+		{"let a = [1, 2, 3]; removeAt(a, 1); a", "[1, 2, 3]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"removeAt([1, 2, 3], 3)", "index out of range: 3, length=3"},
+		{"removeAt([1, 2, 3], -1)", "index out of range: -1, length=3"},
+		{"removeAt([], 0)", "index out of range: 0, length=0"},
+		{`removeAt([1], "0")`, "arguments to `removeAt` must be ARRAY, INTEGER, got=ARRAY, STRING"},
+		{"removeAt([1])", "wrong number of arguments: got=1, want=2"},
+		{`insert("ab", 0, "c")`, "first two arguments to `insert` must be ARRAY, INTEGER, got=STRING, INTEGER"},
+		{"insert([1], 0)", "wrong number of arguments: got=2, want=3"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"repeat(0, 5)", "[0, 0, 0, 0, 0]"},
+		{`repeat("x", 3)`, "[x, x, x]"},
+		{"repeat([1, 2], 2)", "[[1, 2], [1, 2]]"},
+		{"repeat(1, 1)", "[1]"},
+		{"repeat(1, 0)", "[]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("repeat(1, -1)"), "negative repetition count: -1")
+	testErrorObject(t, testEval("repeat(1, 4611686018427387904)"),
+		"repetition result too large: 1 * 4611686018427387904")
+	testErrorObject(t, testEval(`repeat(1, "2")`), "second argument to `repeat` must be INTEGER, got=STRING")
+	testErrorObject(t, testEval("repeat(1)"), "wrong number of arguments: got=1, want=2")
+}
+
+func TestChainedComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 < 5 < 10", true},
+		{"1 < 15 < 10", false},
+		{"10 > 5 > 1", true},
+		{"1 <= 1 < 2 <= 2", true},
+		{"1 < 2 > 0", true},
+		{"1 < 2 > 3", false},
+		{"1 < 2.5 < 3", true},
+		{`"a" < "b" < "c"`, true},
+		// the middle operand is evaluated once
+		{"let n = 0; let mid = fn() { n = n + 1; 5 }; 1 < mid() < 10; n", 1},
+		{"let n = 0; let mid = fn() { n = n + 1; 5 }; 1 < mid() < 3; n", 1},
+		// operands after a failed comparison are not evaluated
+		{"let n = 0; let f = fn() { n = n + 1; 0 }; 3 < 2 < f(); n", 0},
+		{"1 < missing < 3", "identifier not found: missing"},
+		{"1 < 2 < true", "type mismatch: INTEGER < BOOLEAN"},
+		{"2 < 1 < true", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2, 3]; a[1] = 5; a", "[1, 5, 3]"},
+		{"let a = [1, 2, 3]; let r = a[0] = a[1] + a[2]; [r, a]", "[5, [5, 2, 3]]"},
+		{"let a = [1, 2]; let b = a; b[0] = 9; a", "[9, 2]"},
+		{"let m = [[1, 2], [3, 4]]; m[1][0] = 0; m", "[[1, 2], [0, 4]]"},
+		{`let h = {"a": 1}; h["a"] = 2; h["b"] = 3; h`, "{a: 2, b: 3}"},
+		{`let h = {"z": 1, "a": 2}; h["z"] = 0; keys(h)`, "[z, a]"},
+		{`let h = {}; h[[1, 2]] = "pair"; h[[1, 2]]`, "pair"},
+		// the hash keeps its own copy of an array key
+		{`let k = [1]; let h = {}; h[k] = "v"; k[0] = 2; h`, "{[1]: v}"},
+		{`let k = [1]; let h = {}; h[k] = "v"; k[0] = 2; [h[[1]], h[k]]`, "[v, undefined]"},
+		{`let k = [[1]]; let h = {k: "v"}; k[0][0] = 2; h`, "{[[1]]: v}"},
+		{`let k = [0]; let h = {}; h[k] = 1; k[0] = h; h`, "{[0]: 1}"},
+		{`let k = [1]; let h = mapKeys({"a": 1}, fn(x) { k }); k[0] = 2; h`, "{[1]: 1}"},
+		{`let k = [1]; let h = merge({}, {k: 1}); k[0] = 2; h`, "{[1]: 1}"},
+		{`let h = {"list": [1]}; h["list"][0] = 2; h`, "{list: [2]}"},
+		{"let a = [0, 0]; let b = [a]; a[0] = [1]; b", "[[[1], 0]]"},
+		{"let a = [1]; let i = 0; a[i] = a[i] + 1; a[i] = a[i] + 1; a", "[3]"},
+		{"let a = [1]; let b = [a[0] = 2]; b", "[2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; a[1] = 2", "index out of range: 1, length=1"},
+		{"let a = [1]; a[-1] = 2", "index out of range: -1, length=1"},
+		{`let a = [1]; a["0"] = 2`, "array index must be INTEGER, got=STRING"},
+		{`let h = {}; h[{}] = 1`, "unusable as hash key: HASH"},
+		{`let s = "abc"; s[0] = "x"`, "index assignment not supported: STRING"},
+		{"missing[0] = 1", "identifier not found: missing"},
+		{"let a = [1]; a[0] = missing", "identifier not found: missing"},
+		{"let a = [1]; a[0] = a", "cannot store an array inside itself"},
+		{"let a = [1]; let b = [a]; a[0] = b", "cannot store an array inside itself"},
+		{"let h = {[1]: 1}; let k = keys(h)[0]; k[0] = 2", "cannot modify frozen value"},
+		{`let h = {}; h["self"] = [1, {"h": h}]`, "cannot store a hash inside itself"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestClone(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2]; let b = clone(a); b[0] = 9; [a, b]", "[[1, 2], [9, 2]]"},
+		{"let a = [[1], [2]]; let b = clone(a); b[0][0] = 9; [a, b]", "[[[1], [2]], [[9], [2]]]"},
+		{
+			`let h = {"list": [1], "n": 1}; let c = clone(h); c["list"][0] = 2; c["n"] = 3; [h, c]`,
+			"[{list: [1], n: 1}, {list: [2], n: 3}]",
 		},
+		{`let h = {"z": 1, "a": [2]}; keys(clone(h))`, "[z, a]"},
+		{`clone({[1, 2]: "pair"})[[1, 2]]`, "pair"},
+		// a value shared within the original is shared within the copy
+		{"let x = [1]; let c = clone([x, x]); c[0][0] = 2; c", "[[2], [2]]"},
+		{"clone(5)", "5"},
+		{`clone("text")`, "text"},
+		{"clone([])", "[]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("clone()"), "wrong number of arguments: got=0, want=1")
+
+	// scripts cannot build cycles, but host code can
+	cyclic := &object.Array{Elements: []object.Object{&object.Integer{Value: 1}, nil}}
+	cyclic.Elements[1] = cyclic
+	copied, ok := builtins["clone"].Fn(cyclic).(*object.Array)
+	if !ok {
+		t.Fatalf("clone did not return an array")
+	}
+	if copied == cyclic || copied.Elements[1] != copied {
+		t.Errorf("cycle not preserved in copy")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = freeze([1, 2]); a", "[1, 2]"},
+		{"let a = [1, 2]; freeze(a); a[0] = 3", "ERROR: cannot modify frozen value"},
+		{`let h = freeze({"a": 1}); h["a"] = 2`, "ERROR: cannot modify frozen value"},
+		{`let h = freeze({"a": 1}); h["b"] = 2`, "ERROR: cannot modify frozen value"},
+		{`let h = freeze({"a": 1}); h["a"]`, "1"},
+		// building new values from a frozen one is fine
+		{"let a = freeze([1, 2]); push(a, 3)", "[1, 2, 3]"},
+		{"let a = freeze([1, 2]); let b = push(a, 3); b[0] = 0; [a, b]", "[[1, 2], [0, 2, 3]]"},
+		{"let a = freeze([1]); let b = clone(a); b[0] = 2; [a, b]", "[[1], [2]]"},
+		{"let a = freeze([1]); a + [2]", "[1, 2]"},
+		// freezing is shallow
+		{"let a = freeze([[1]]); a[0][0] = 2; a", "[[2]]"},
+		{"let inner = [1]; let a = freeze([inner]); inner[0] = 2; a", "[[2]]"},
+		// other containers stay mutable
+		{"let a = [1]; let b = [1]; freeze(a); b[0] = 2; b", "[2]"},
+		{"freeze(freeze([1]))", "[1]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval("freeze(1)"), "argument to `freeze` must be ARRAY or HASH, got=INTEGER")
+	testErrorObject(t, testEval("freeze()"), "wrong number of arguments: got=0, want=1")
+}
+
+func TestToBase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"toBase(10, 2)", "1010"},
+		{"toBase(255, 16)", "ff"},
+		{"toBase(8, 8)", "10"},
+		{"toBase(35, 36)", "z"},
+		{"toBase(0, 2)", "0"},
+		{"toBase(-255, 16)", "-ff"},
+		{"toBase(99999999999999999999, 16)", "56bc75e2d630fffff"},
+		{"toBase(10, 1)", "ERROR: invalid base for `toBase`: 1, want 2 to 36"},
+		{"toBase(10, 37)", "ERROR: invalid base for `toBase`: 37, want 2 to 36"},
+		{`toBase("10", 2)`, "ERROR: first argument to `toBase` must be INTEGER, got=STRING"},
+		{"toBase(10, 2.0)", "ERROR: second argument to `toBase` must be INTEGER, got=FLOAT"},
+		{"toBase(10)", "ERROR: wrong number of arguments: got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`parseInt("ff", 16)`, "255"},
+		{`parseInt("FF", 16)`, "255"},
+		{`parseInt("1010", 2)`, "10"},
+		{`parseInt("-z", 36)`, "-35"},
+		{`parseInt("0", 8)`, "0"},
+		{`parseInt("56bc75e2d630fffff", 16)`, "99999999999999999999"},
+		{`parseInt(toBase(12345, 7), 7)`, "12345"},
+		{`parseInt("12", 2)`, `ERROR: could not parse "12" as integer in base 2`},
+		{`parseInt("0xff", 16)`, `ERROR: could not parse "0xff" as integer in base 16`},
+		{`parseInt("", 10)`, `ERROR: could not parse "" as integer in base 10`},
+		{`parseInt("1", 0)`, "ERROR: invalid base for `parseInt`: 0, want 2 to 36"},
+		{`parseInt(1, 10)`, "ERROR: first argument to `parseInt` must be STRING, got=INTEGER"},
+		{`parseInt("1", "10")`, "ERROR: second argument to `parseInt` must be INTEGER, got=STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestUnset(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x = 1; unset("x")`, "true"},
+		{`let x = 1; unset("x"); x`, "ERROR: identifier not found: x"},
+		{`unset("missing")`, "false"},
+		{`let x = 1; unset("x"); unset("x")`, "false"},
+		{`let x = 1; let x = 2; unset("x"); let x = 3; x`, "3"},
+		// a builtin shows through again once its shadow is gone
+		{`let len = 5; unset("len"); len("abc")`, "3"},
+		// only the scope of the call is affected
+		{`let x = 1; let f = fn() { unset("x") }; [f(), x]`, "[false, 1]"},
+		{`let x = 1; let f = fn(x) { unset("x"); x }; f(2)`, "1"},
+		{`unset(1)`, "ERROR: argument to `unset` must be STRING, got=INTEGER"},
+		{`unset()`, "ERROR: wrong number of arguments: got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestGetOrDefault(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`getOrDefault({"a": 1}, "a", 0)`, 1},
+		{`getOrDefault({"a": 1}, "b", 0)`, 0},
+		{`getOrDefault({}, 5, 42)`, 42},
+		{`getOrDefault({1: 10, true: 20}, true, 0)`, 20},
+		{`getOrDefault({"1": 10}, 1, 99)`, 99},
+		{`isNull(getOrDefault({"a": if (false) { 1 }}, "a", 0))`, true},
+		{`getOrDefault({[1, 2]: 3}, [1, 2], 0)`, 3},
+		{`getOrDefault({"a": 1}, [{}], 0)`, "unusable as hash key: ARRAY"},
+		{`getOrDefault([1], 0, 0)`, "argument to `getOrDefault` must be HASH, got=ARRAY"},
+		{`getOrDefault({}, "a")`, "wrong number of arguments: got=2, want=3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`merge({"a": 1}, {"b": 2})`, "{a: 1, b: 2}"},
+		{`merge({"a": 1}, {"b": 2, "a": 3})`, "{a: 3, b: 2}"},
+		{`merge({"a": 1}, {"b": 2}, {"a": 3, "c": 4})`, "{a: 3, b: 2, c: 4}"},
+		{`merge({}, {})`, "{}"},
+		{`let h = {"a": 1}; merge(h, {"a": 2}); h`, "{a: 1}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`merge({"a": 1}, [1])`), "argument to `merge` must be HASH, got=ARRAY")
+	testErrorObject(t, testEval(`merge({"a": 1})`), "wrong number of arguments: got=1, want at least 2")
+}
+
+func TestArrayHashKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{[1, 2]: "a"}[[1, 2]]`, "a"},
+		{`let point = [3, 4]; let grid = {point: "x"}; grid[[3, 4]]`, "x"},
+		{`{[1, 2]: "a"}[[2, 1]]`, nil},
+		{`{[1, "2"]: "a"}[[1, 2]]`, nil},
+		{`{[[1], [2]]: "nested"}[[[1], [2]]]`, "nested"},
+		{`{[]: "empty"}[[]]`, "empty"},
+		{`let h = {[1, 2]: "a", [1, 2]: "b"}; [len(h), h[[1, 2]]]`, "[1, b]"},
+		{`{[1, 2]: "a", "k": 1}`, "{[1, 2]: a, k: 1}"},
+		{`{[1, {}]: 1}`, "unusable as hash key: ARRAY"},
+		{`{"a": 1}[[fn() { 1 }]]`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case nil:
+			testUndefinedObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			case *object.String:
+				testStringObject(t, obj, expected)
+			default:
+				if obj.Inspect() != expected {
+					t.Errorf("%s: expected=%s, got=%s", tt.input, expected, obj.Inspect())
+				}
+			}
+		}
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
 		{
-			"[1, 2, 3][2]",
-			3,
+			`{"foo": 5}["foo"]`,
+			5,
 		},
 		{
-			"let i = 0; [1][i];",
-			1,
+			`{"foo": 5}["bar"]`,
+			nil,
 		},
 		{
-			"[1, 2, 3][1 + 1];",
-			3,
+			`let key = "foo"; {"foo": 5}[key]`,
+			5,
 		},
 		{
-			"let myArray = [1, 2, 3]; myArray[2];",
-			3,
+			`{}["foo"]`,
+			nil,
 		},
 		{
-			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
-			6,
+			`{5: 5}[5]`,
+			5,
 		},
 		{
-			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
-			2,
+			`{true: 5}[true]`,
+			5,
 		},
 		{
-			"[1, 2, 3][3]",
-			nil,
+			`{false: 5}[false]`,
+			5,
 		},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testUndefinedObject(t, evaluated)
+		}
+	}
+}
+
+// big integers
+func TestBigIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
 		{
-			"[1, 2, 3][-1]",
-			nil,
+			`
+			let factorial = fn(n) {
+				if (n < 2) { 1 } else { n * factorial(n - 1) }
+			};
+			factorial(50);
+			`,
+			"30414093201713378043612608166064768844377641568960512000000000000",
 		},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4611686018427387904 * 4", "18446744073709551616"},
+		{"99999999999999999999", "99999999999999999999"},
+		{"-99999999999999999999", "-99999999999999999999"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"let min = -9223372036854775808; -min", "9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result, ok := evaluated.(*object.BigInt)
+		if !ok {
+			t.Errorf("obj is not BigInt got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("result.Inspect(): expected=%s, got=%s",
+				tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestBigIntegerDemotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1 - 1", 9223372036854775807},
+		{"99999999999999999999 / 99999999999999999999", 1},
+		{"99999999999999999999 > 1", true},
+		{"99999999999999999999 == 99999999999999999999", true},
+		{"1 < 99999999999999999999", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestNegateMinInt64(t *testing.T) {
+	min := &object.Integer{Value: math.MinInt64}
+	negated := evalMinusPrefixOperatorExpression(min)
+
+	result, ok := negated.(*object.BigInt)
+	if !ok {
+		t.Fatalf("negating MinInt64 should give a BigInt, got=%T (%+v)", negated, negated)
+	}
+	if result.Inspect() != "9223372036854775808" {
+		t.Errorf("result.Inspect(): expected=%s, got=%s",
+			"9223372036854775808", result.Inspect())
 	}
+	if min.Value != math.MinInt64 {
+		t.Errorf("operand was modified, got=%d", min.Value)
+	}
+
+	// negating back demotes to an ordinary integer
+	testIntegerObject(t, evalMinusPrefixOperatorExpression(result), math.MinInt64)
+	testIntegerObject(t, testEval("-0"), 0)
+}
+
+func TestEmptyProgramsEvaluateToNull(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"\n\t\r\n",
+		"fn() {}()",
+		"if (true) {}",
+		"let f = fn() {}; f()",
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+		if evaluated == nil {
+			t.Errorf("%q: Eval returned nil", input)
+			continue
+		}
+		if evaluated != NULL {
+			t.Errorf("%q: expected NULL, got=%T (%+v)", input, evaluated, evaluated)
+		}
+	}
+}
+
+// assignment and postfix operators
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = 1; a = 2; a;", 2},
+		{"let a = 1; let b = a = a + 1; b;", 2},
+		{"let a = 1; let b = 1; a = b = 3; a + b;", 6},
+		{"let a = 1; let set = fn() { a = 10; }; set(); a;", 10},
+		{"b = 1;", "identifier not found: b"},
+		{"let a = 1; a = true + 1;", "type mismatch: BOOLEAN + INTEGER"},
+	}
+
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned, got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("errObj.Message: expected=%q, got=%q",
+					expected, errObj.Message)
+			}
 		}
 	}
 }
 
-// hash map
-func TestHashLiterals(t *testing.T) {
-	input := `let two = "two";
-	{
-		"one": 10 - 9,
-		two: 1 + 1,
-		"thr" + "ee": 6 / 2,
-		4: 4,
-		true: 5,
-		false: 6
-	}`
-
-	evaluated := testEval(input)
-	result, ok := evaluated.(*object.Hash)
-	if !ok {
-		t.Fatalf("evaluated is not *object.Hash, got=%T (%+v)",
-			evaluated, evaluated)
+func TestPostfixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; i++; i;", 1},
+		{"let i = 0; i--; i;", -1},
+		{"let i = 5; i++;", 5},
+		{"let i = 5; i++ + i;", 11},
+		{"let i = 0; let inc = fn() { i++ }; inc(); inc(); inc(); i;", 3},
+		{`let s = "a"; s++;`, "unknown operator: STRING++"},
+		{"let b = true; b--;", "unknown operator: BOOLEAN--"},
+		{"missing++;", "identifier not found: missing"},
 	}
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned, got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("errObj.Message: expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
 	}
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("len(result.Pairs): expected=%d, got=%d",
-			len(expected), len(result.Pairs))
+}
+
+// loops
+func TestWhileExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 10) { i++ }; i;", 10},
+		{"let i = 10; while (i > 0) { i-- }; i;", 0},
+		{"let i = 0; let sum = 0; while (i < 5) { i++; sum = sum + i; }; sum;", 15},
+		{"let i = 0; while (false) { i++ }; i;", 0},
+		{"let f = fn() { let i = 0; while (true) { i++; if (i == 3) { return i; } } }; f();", 3},
+		{"while (1 + true) { 1 }", "type mismatch: INTEGER + BOOLEAN"},
+		{"let i = 0; while (i < 3) { i++; i + true; }", "type mismatch: INTEGER + BOOLEAN"},
 	}
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
-		if !ok {
-			t.Fatalf("no value for given key in Pairs")
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned, got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("errObj.Message: expected=%q, got=%q",
+					expected, errObj.Message)
+			}
 		}
-		testIntegerObject(t, pair.Value, expectedValue)
 	}
 }
 
-// access hash map by keys
-func TestHashIndexExpressions(t *testing.T) {
+func TestDoWhileExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let i = 0; do { i++ } while (false); i;", 1},
+		{"let i = 10; do { i++ } while (i < 5); i;", 11},
+		{"let i = 0; do { i++ } while (i < 5); i;", 5},
+		{"let f = fn() { do { return 7; } while (false); 0 }; f();", 7},
+		{"let f = fn() { let i = 0; do { i++; if (i == 4) { return i; } } while (true) }; f();", 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestForExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let sum = 0; for (let i = 1; i < 1; i++) { sum = sum + i }; sum;", 0},
+		{"let sum = 0; for (let i = 1; i < 5; i++) { sum = sum + i }; sum;", 10},
+		{"let i = 0; for (; i < 3;) { i++ }; i;", 3},
+		{"let i = 7; for (let i = 0; i < 3; i++) { }; i;", 7},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTryExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected interface{}
 	}{
+		{"try { 1 + 1 } catch (e) { 0 }", 2},
+		{`try { 1 + "a" } catch (e) { 0 }`, 0},
+		{`try { 1 + "a" } catch (e) { e["message"] }`, "type mismatch: INTEGER + STRING"},
+		{`try { 1 + "a" } catch (e) { e }`, "{message: type mismatch: INTEGER + STRING}"},
+		{`let f = fn() { missing }; try { f() } catch (e) { "caught" }`, "caught"},
 		{
-			`{"foo": 5}["foo"]`,
-			5,
-		},
-		{
-			`{"foo": 5}["bar"]`,
-			nil,
-		},
-		{
-			`let key = "foo"; {"foo": 5}[key]`,
-			5,
+			`let handle = fn(err) { "handled " + err["message"] };
+			try { missing } catch (e) { handle(e) }`,
+			"handled identifier not found: missing",
 		},
+		{`let f = fn() { f() }; try { f() } catch (e) { e["message"] }`, "maximum call depth exceeded"},
+		{"let x = 0; try { x = 1; x + true; x = 2 } catch (e) { x = x + 10 }; x", 11},
+		{"let x = 0; try { x = 1 } catch (e) { x = 5 }; x", 1},
+		{`try { 5 / 0 } catch (e) { e["message"] }`, "division by zero"},
+		{"let f = fn() { try { return 1; } catch (e) { 2 }; 3 }; f()", 1},
+		{`try { try { -true } catch (e) { 1 + e } } catch (e) { e["message"] }`, "type mismatch: INTEGER + HASH"},
+		{`try { try { -true } catch (e) { e["message"] } } catch (e) { "outer" }`, "unknown operator: -BOOLEAN"},
+		{"let e = 1; try { -true } catch (e) { 2 }; e", 1},
+		// the handler has its own scope and errors inside it propagate
+		{`try { 1 + "a" } catch (e) { 1 }; e`, "identifier not found: e"},
+		{`try { 1 + "a" } catch (e) { e + 1 }`, "type mismatch: HASH + INTEGER"},
+		{`try { 1 + "a" } catch (e) { let x = 1 }; x`, "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			case *object.String:
+				testStringObject(t, obj, expected)
+			default:
+				if obj.Inspect() != expected {
+					t.Errorf("%s: expected=%s, got=%s", tt.input, expected, obj.Inspect())
+				}
+			}
+		}
+	}
+}
+
+func TestErrorBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`error("something broke")`, "something broke"},
+		{`error("100% broken")`, "100% broken"},
+		{`let x = 1; error("stop"); x = 2; x`, "stop"},
+		{`let f = fn() { error("deep"); 1 }; f() + 1`, "deep"},
+		{`[1, error("in array"), 3]`, "in array"},
+		{`try { error("oops") } catch (e) { e["message"] }`, "oops"},
+		{`try { error("bad input", "ValueError") } catch (e) { e["kind"] }`, "ValueError"},
+		{`try { error("plain") } catch (e) { e["kind"] }`, nil},
+		{`try { 1 + "a" } catch (e) { "kind" in e }`, false},
 		{
-			`{}["foo"]`,
-			nil,
+			`let parse = fn(s) { if (s == "") { error("empty", "ParseError") } else { s } };
+			let safe = fn(s) { try { parse(s) } catch (e) { "default" } };
+			[safe("x"), safe("")]`,
+			"[x, default]",
 		},
+		{"error(1)", "argument to `error` must be STRING, got=INTEGER"},
+		{`error("a", 1)`, "second argument to `error` must be STRING, got=INTEGER"},
+		{"error()", "wrong number of arguments: got=0, want=1 or 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			case *object.String:
+				testStringObject(t, obj, expected)
+			default:
+				if obj.Inspect() != expected {
+					t.Errorf("%s: expected=%s, got=%s", tt.input, expected, obj.Inspect())
+				}
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testUndefinedObject(t, evaluated)
+		}
+	}
+
+	err := testEval(`error("bad input", "ValueError")`)
+	if err.Inspect() != "ERROR: ValueError: bad input" {
+		t.Errorf("wrong inspect output: got=%q", err.Inspect())
+	}
+}
+
+func TestLoopValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let i = 0; while (i < 3) { i = i + 1; i * 10 }", "30"},
+		{"let i = 0; let x = while (i < 3) { i = i + 1; [i] }; x", "[3]"},
+		{"while (false) { 1 }", "null"},
+		{"let i = 0; do { i = i + 1; i } while (i < 4)", "4"},
+		{"do { 5 } while (false)", "5"},
+		{"for (let i = 0; i < 4; i++) { i * i }", "9"},
+		{"for (let i = 0; i < 0; i++) { i }", "null"},
+		{"let i = 0; while (i < 2) { i = i + 1; }", "2"},
+		{"while (false) { }", "null"},
+		// break and continue keep the value of the last full iteration
+		{"let i = 0; while (true) { i = i + 1; if (i == 3) { break; } i }", "2"},
+		{"for (let i = 0; i < 5; i++) { if (i > 1) { continue; } i }", "1"},
+		{"while (true) { break; }", "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// while
+		{"let i = 0; while (true) { i++; if (i == 5) { break; } }; i;", 5},
 		{
-			`{5: 5}[5]`,
-			5,
+			`let i = 0; let sum = 0;
+			while (i < 10) { i++; if (i > 3) { continue; } sum = sum + i; };
+			sum;`,
+			6,
 		},
+		// do-while
+		{"let i = 0; do { i++; break; i++; } while (true); i;", 1},
+		// for
+		{"let n = 0; for (let i = 0; i < 100; i++) { if (i == 4) { break; } n++ }; n;", 4},
 		{
-			`{true: 5}[true]`,
-			5,
+			`let sum = 0;
+			for (let i = 0; i < 10; i++) { if (i / 2 * 2 == i) { continue; } sum = sum + i; };
+			sum;`,
+			25,
 		},
+		// nested loops only affect the innermost loop
 		{
-			`{false: 5}[false]`,
-			5,
+			`let count = 0;
+			for (let i = 0; i < 3; i++) {
+				for (let j = 0; j < 10; j++) { if (j == 2) { break; } count++; }
+			};
+			count;`,
+			6,
 		},
+		// outside a loop
+		{"break;", "break outside loop"},
+		{"continue;", "continue outside loop"},
+		{"let f = fn() { break; }; while (true) { f(); }", "break outside loop"},
 	}
+
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned, got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("errObj.Message: expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+// switch
+func TestSwitchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`switch (1) { case 1: "one"; case 2: "two"; default: "other" }`, "one"},
+		{`switch (2) { case 1: "one"; case 2: "two"; default: "other" }`, "two"},
+		{`switch (3) { case 1: "one"; case 2: "two"; default: "other" }`, "other"},
+		{`switch (3) { case 1: "one"; case 2: "two" }`, nil},
+		{`switch ("b") { case "a": 1; case "b": 2 }`, 2},
+		{`switch (true) { case 1 > 2: 1; case 2 > 1: 2 }`, 2},
+		{`switch (1) { case "1": "string"; default: "int" }`, "int"},
+		{`let x = 0; switch (1) { case 1: x = 1; case 1: x = 2 }; x;`, 1},
+		{`let f = fn(n) { switch (n) { case 0: return "zero"; } "nonzero" }; f(0);`, "zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		default:
 			testNullObject(t, evaluated)
 		}
 	}
 }
+
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Monkey"; "hello ${name}"`, "hello Monkey"},
+		{`"1 + 2 = ${1 + 2}"`, "1 + 2 = 3"},
+		{`let a = [1, 2]; "${a} has ${len(a)} items"`, "[1, 2] has 2 items"},
+		{`"${true}${false}"`, "truefalse"},
+		{`let f = fn(x) { x * 2 }; "twice: ${f(21)}!"`, "twice: 42!"},
+		{`"no interpolation $ here {}"`, "no interpolation $ here {}"},
+		{"let x = 1; `${x}`", "${x}"},
+		{"`${missing}`", "${missing}"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval(`"${missing}"`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned, got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: missing" {
+		t.Errorf("errObj.Message: expected=%q, got=%q",
+			"identifier not found: missing", errObj.Message)
+	}
+}
+
+func TestEvalStream(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"1; 2; 3;", []string{"1", "2", "3"}},
+		{"let x = 2; x * 2; [x]", []string{"null", "4", "[2]"}},
+		{"1; return 2; 3;", []string{"1", "2"}},
+		{"1; missing; 3;", []string{"1", "ERROR: identifier not found: missing"}},
+		{"1; break; 3;", []string{"1", "ERROR: break outside loop"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		results := []string{}
+		final := EvalStream(program, object.NewEnvironment(), func(obj object.Object) {
+			results = append(results, obj.Inspect())
+		})
+
+		if len(results) != len(tt.expected) {
+			t.Errorf("%s: expected results %v, got=%v", tt.input, tt.expected, results)
+			continue
+		}
+		for i := range results {
+			if results[i] != tt.expected[i] {
+				t.Errorf("%s: results[%d] expected=%s, got=%s",
+					tt.input, i, tt.expected[i], results[i])
+			}
+		}
+		if len(results) > 0 && final.Inspect() != results[len(results)-1] {
+			t.Errorf("%s: final result %s is not the last streamed result %s",
+				tt.input, final.Inspect(), results[len(results)-1])
+		}
+	}
+}
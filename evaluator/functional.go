@@ -0,0 +1,240 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+// builtins that take functions as arguments. They call back into
+// applyFunction, which would make the builtins table depend on itself if
+// they were part of its literal, so they are added here instead.
+func init() {
+	builtins["compose"] = &object.Builtin{Fn: compose}
+	builtins["partial"] = &object.Builtin{Fn: partial}
+	builtins["memoize"] = &object.Builtin{Fn: memoize}
+	builtins["each"] = &object.Builtin{EnvFn: each}
+	builtins["mapValues"] = &object.Builtin{EnvFn: mapHash("mapValues", false)}
+	builtins["mapKeys"] = &object.Builtin{EnvFn: mapHash("mapKeys", true)}
+}
+
+func isCallable(obj object.Object) bool {
+	return obj.Type() == object.FUNCTION_OBJ || obj.Type() == object.BUILTIN_OBJ
+}
+
+// compose(f, g, h) returns a function computing f(g(h(...))), the rightmost
+// function receives all the arguments of the call
+func compose(args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return newError(
+			"wrong number of arguments: got=%d, want at least 2",
+			len(args),
+		)
+	}
+	for i, arg := range args {
+		if !isCallable(arg) {
+			return newError("argument %d to `compose` must be a function, got=%s",
+				i+1, arg.Type())
+		}
+	}
+	fns := make([]object.Object, len(args))
+	copy(fns, args)
+
+	return &object.Builtin{
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			result := applyFunction(fns[len(fns)-1], args, env)
+			for i := len(fns) - 2; i >= 0; i-- {
+				if isError(result) {
+					return result
+				}
+				result = applyFunction(fns[i], []object.Object{result}, env)
+			}
+			return result
+		},
+	}
+}
+
+// partial(f, a, b) returns a function that calls f with a and b followed by
+// its own arguments
+func partial(args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(
+			"wrong number of arguments: got=%d, want at least 1",
+			len(args),
+		)
+	}
+	if !isCallable(args[0]) {
+		return newError("argument to `partial` must be a function, got=%s",
+			args[0].Type())
+	}
+	fn := args[0]
+	bound := make([]object.Object, len(args)-1)
+	copy(bound, args[1:])
+
+	return &object.Builtin{
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			callArgs := make([]object.Object, 0, len(bound)+len(args))
+			callArgs = append(callArgs, bound...)
+			callArgs = append(callArgs, args...)
+			return applyFunction(fn, callArgs, env)
+		},
+	}
+}
+
+// memoize(f) returns a function that caches the results of f by argument
+// values, so f runs once per distinct set of arguments. Calls are matched on
+// the type and Inspect() of each argument (of each element for arrays and
+// hashes), which is only sound when f is pure: side effects are skipped on
+// a cache hit, and a function that reads state which changes between calls
+// will keep returning stale results. Calls passing a function are never
+// cached, as different functions can inspect the same. Errors are not
+// cached.
+func memoize(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(
+			"wrong number of arguments: got=%d, want=%d",
+			len(args),
+			1,
+		)
+	}
+	if !isCallable(args[0]) {
+		return newError("argument to `memoize` must be a function, got=%s",
+			args[0].Type())
+	}
+	fn := args[0]
+	cache := map[string]object.Object{}
+
+	return &object.Builtin{
+		EnvFn: func(env *object.Environment, args ...object.Object) object.Object {
+			key, ok := memoKey(args)
+			if !ok {
+				return applyFunction(fn, args, env)
+			}
+			if result, ok := cache[key]; ok {
+				return result
+			}
+			result := applyFunction(fn, args, env)
+			if !isError(result) {
+				cache[key] = result
+			}
+			return result
+		},
+	}
+}
+
+// each(arr, f) calls f on every element of arr for its side effects and
+// returns null. An error from f stops the iteration and is returned.
+func each(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(
+			"wrong number of arguments: got=%d, want=%d",
+			len(args),
+			2,
+		)
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("first argument to `each` must be ARRAY, got=%s",
+			args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return newError("second argument to `each` must be a function, got=%s",
+			args[1].Type())
+	}
+	for _, el := range arr.Elements {
+		result := applyFunction(args[1], []object.Object{el}, env)
+		if isError(result) {
+			return result
+		}
+	}
+	return NULL
+}
+
+// mapHash builds mapValues and mapKeys, which return a new hash with f
+// applied to every value or every key of the given one. When two keys map to
+// the same new key the later pair wins.
+func mapHash(name string, keys bool) func(*object.Environment, ...object.Object) object.Object {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError(
+				"wrong number of arguments: got=%d, want=%d",
+				len(args),
+				2,
+			)
+		}
+		hash, ok := args[0].(*object.Hash)
+		if !ok {
+			return newError("first argument to `%s` must be HASH, got=%s",
+				name, args[0].Type())
+		}
+		if !isCallable(args[1]) {
+			return newError("second argument to `%s` must be a function, got=%s",
+				name, args[1].Type())
+		}
+		mapped := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+		for _, pair := range hash.OrderedPairs() {
+			if !keys {
+				value := applyFunction(args[1], []object.Object{pair.Value}, env)
+				if isError(value) {
+					return value
+				}
+				hashKey, _ := object.HashKeyOf(pair.Key)
+				mapped.Set(hashKey, object.HashPair{Key: pair.Key, Value: value})
+				continue
+			}
+			key := applyFunction(args[1], []object.Object{pair.Key}, env)
+			if isError(key) {
+				return key
+			}
+			hashKey, ok := object.HashKeyOf(key)
+			if !ok {
+				return newError("unusable as hash key: %s", key.Type())
+			}
+			mapped.Set(hashKey, object.HashPair{Key: key, Value: pair.Value})
+		}
+		return mapped
+	}
+}
+
+// memoKey encodes the arguments so that different ones never share a key.
+// Every value starts with its type, so 1 and "1" are told apart, Inspect()
+// output is length prefixed and arrays and hashes are encoded element by
+// element, so [1] and ["1"] differ too. It reports false when an argument
+// holds a function, which has no such encoding.
+func memoKey(args []object.Object) (string, bool) {
+	var key strings.Builder
+	for _, arg := range args {
+		if !writeMemoKey(&key, arg) {
+			return "", false
+		}
+	}
+	return key.String(), true
+}
+
+func writeMemoKey(key *strings.Builder, obj object.Object) bool {
+	key.WriteString(string(obj.Type()))
+	switch obj := obj.(type) {
+	case *object.Function, *object.Builtin:
+		return false
+	case *object.Array:
+		fmt.Fprintf(key, "[%d]", len(obj.Elements))
+		for _, el := range obj.Elements {
+			if !writeMemoKey(key, el) {
+				return false
+			}
+		}
+	case *object.Hash:
+		pairs := obj.OrderedPairs()
+		fmt.Fprintf(key, "{%d}", len(pairs))
+		for _, pair := range pairs {
+			if !writeMemoKey(key, pair.Key) || !writeMemoKey(key, pair.Value) {
+				return false
+			}
+		}
+	default:
+		value := obj.Inspect()
+		fmt.Fprintf(key, ":%d:%s", len(value), value)
+	}
+	return true
+}
@@ -0,0 +1,321 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/anukuljoshi/monkey/lexer"
+	"github.com/anukuljoshi/monkey/object"
+	"github.com/anukuljoshi/monkey/parser"
+)
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`let inc = fn(x) { x + 1 };
+			let double = fn(x) { x * 2 };
+			compose(inc, double)(5)`,
+			11,
+		},
+		{
+			`let inc = fn(x) { x + 1 };
+			let double = fn(x) { x * 2 };
+			compose(double, inc)(5)`,
+			12,
+		},
+		{
+			`let inc = fn(x) { x + 1 };
+			let double = fn(x) { x * 2 };
+			let square = fn(x) { x * x };
+			compose(inc, double, square)(3)`,
+			19,
+		},
+		{
+			`let add = fn(a, b) { a + b };
+			let negate = fn(x) { -x };
+			compose(negate, add)(2, 3)`,
+			-5,
+		},
+		{"compose(len, rest)([1, 2, 3])", 2},
+		{
+			`let early = fn(x) { return x * 10; 0 };
+			compose(early, early)(1)`,
+			100,
+		},
+		{
+			`let fail = fn(x) { x + "a" };
+			let double = fn(x) { x * 2 };
+			compose(double, fail)(1)`,
+			"type mismatch: INTEGER + STRING",
+		},
+		{
+			`let fail = fn(x) { x + "a" };
+			let never = fn(x) { never_called };
+			compose(fail, never)(1)`,
+			"identifier not found: never_called",
+		},
+		{"compose(len)", "wrong number of arguments: got=1, want at least 2"},
+		{"compose(len, 1)", "argument 2 to `compose` must be a function, got=INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestPartial(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let add = fn(a, b) { a + b }; let addFive = partial(add, 5); addFive(3)", 8},
+		{"let sub = fn(a, b) { a - b }; partial(sub, 10)(3)", 7},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; partial(f, 1)(2, 3)", 123},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; partial(f, 1, 2)(3)", 123},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; partial(partial(f, 1), 2)(3)", 123},
+		{"let f = fn(a, b) { a + b }; partial(f, 1, 2)()", 3},
+		{"let f = fn(a, b) { a + b }; partial(f)(1, 2)", 3},
+		{"partial(push, [1])(2)[1]", 2},
+		{
+			"let add = fn(a, b) { a + b }; partial(add, 1)(2, 3)",
+			"wrong number of arguments: got=3, want=2",
+		},
+		{
+			"let add = fn(a, b) { a + b }; partial(add, 1, 2, 3)()",
+			"wrong number of arguments: got=3, want=2",
+		},
+		{"partial(1, 2)", "argument to `partial` must be a function, got=INTEGER"},
+		{"partial()", "wrong number of arguments: got=0, want at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestMemoize(t *testing.T) {
+	calls := 0
+	env := object.NewEnvironment()
+	env.Set("counted", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			calls++
+			return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+		},
+	})
+
+	input := `
+let fast = memoize(counted);
+[fast(2), fast(2), fast(3), fast(2), fast(3)]`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	evaluated := Eval(program, env)
+
+	if evaluated.Inspect() != "[4, 4, 6, 4, 6]" {
+		t.Errorf("wrong results. got=%s", evaluated.Inspect())
+	}
+	if calls != 2 {
+		t.Errorf("wrapped function should run once per argument, ran %d times", calls)
+	}
+
+	// errors are not cached, the next call tries again
+	attempts := 0
+	flaky := memoize(&object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			attempts++
+			if attempts == 1 {
+				return newError("not yet")
+			}
+			return args[0]
+		},
+	}).(*object.Builtin)
+	testErrorObject(t, flaky.EnvFn(env, &object.Integer{Value: 1}), "not yet")
+	testIntegerObject(t, flaky.EnvFn(env, &object.Integer{Value: 1}), 1)
+	testIntegerObject(t, flaky.EnvFn(env, &object.Integer{Value: 1}), 1)
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got=%d", attempts)
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`let fib = memoize(fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } });
+			fib(80)`,
+			23416728348467685,
+		},
+		{
+			`let calls = 0;
+			let describe = memoize(fn(x) { calls = calls + 1; x });
+			describe(1); describe("1"); describe(1);
+			calls`,
+			2,
+		},
+		{"memoize(1)", "argument to `memoize` must be a function, got=INTEGER"},
+		{"memoize(len, len)", "wrong number of arguments: got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestMemoizeKeys(t *testing.T) {
+	// arguments that Inspect() the same must not share a cached result
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let join = memoize(fn(a, b) { a + "|" + b });
+			[join("a,STRING:b", "c"), join("a", "b,STRING:c")]`,
+			"[a,STRING:b|c, a|b,STRING:c]",
+		},
+		{
+			`let first = memoize(fn(arr) { arr[0] });
+			[isString(first([1])), isString(first(["1"]))]`,
+			"[false, true]",
+		},
+		{
+			`let count = memoize(fn(h) { len(keys(h)) });
+			[count({"a": 1}), count({"a": 1, "b": 2}), count({})]`,
+			"[1, 2, 0]",
+		},
+		{
+			`let pair = memoize(fn(a, b) { [a, b] });
+			[pair([1, 2], [3]), pair([1], [2, 3])]`,
+			"[[[1, 2], [3]], [[1], [2, 3]]]",
+		},
+		// functions are not keyed by their source
+		{
+			`let mk = fn(n) { fn() { n } };
+			let m = memoize(fn(g) { g() });
+			[m(mk(1)), m(mk(2))]`,
+			"[1, 2]",
+		},
+		{
+			`let m = memoize(fn(g) { g([5, 6]) });
+			[m(first), m(last)]`,
+			"[5, 6]",
+		},
+		{
+			`let m = memoize(fn(h) { h["f"]() });
+			[m({"f": fn() { 1 }}), m({"f": fn() { 2 }})]`,
+			"[1, 2]",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEach(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let count = 0; each([1, 2, 3], fn(x) { count = count + 1 }); count", 3},
+		{"let sum = 0; each([1, 2, 3], fn(x) { sum = sum + x }); sum", 6},
+		{"let count = 0; each([], fn(x) { count = count + 1 }); count", 0},
+		{
+			`let seen = 0;
+			each([1, 2, "a", 4], fn(x) { seen = seen + 1; x + 1 })`,
+			"type mismatch: STRING + INTEGER",
+		},
+		{"each(1, len)", "first argument to `each` must be ARRAY, got=INTEGER"},
+		{"each([1], 1)", "second argument to `each` must be a function, got=INTEGER"},
+		{"each([1])", "wrong number of arguments: got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+
+	testNullObject(t, testEval("each([1, 2], fn(x) { x })"))
+
+	// iteration stops at the first error
+	calls := 0
+	env := object.NewEnvironment()
+	env.Set("counted", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			calls++
+			if args[0].Type() != object.INTEGER_OBJ {
+				return newError("not a number")
+			}
+			return args[0]
+		},
+	})
+	program := parser.New(lexer.New(`each([1, "a", 2, 3], counted)`)).ParseProgram()
+	testErrorObject(t, Eval(program, env), "not a number")
+	if calls != 2 {
+		t.Errorf("expected iteration to stop after 2 calls, got=%d", calls)
+	}
+}
+
+func TestMapValuesAndKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`mapValues({"a": 1, "b": 2}, fn(v) { v * 2 })`, "{a: 2, b: 4}"},
+		{`mapValues({}, fn(v) { v * 2 })`, "{}"},
+		{`let h = {"a": 1}; mapValues(h, fn(v) { v + 1 }); h`, "{a: 1}"},
+		{`mapValues({"a": "x"}, len)`, "{a: 1}"},
+		{`mapKeys({"a": 1, "b": 2}, fn(k) { k + k })`, "{aa: 1, bb: 2}"},
+		{`mapKeys({1: "one", 2: "two"}, fn(k) { k * 10 })`, "{10: one, 20: two}"},
+		{`mapKeys({"a": 1, "bb": 2, "c": 3}, len)`, "{1: 3, 2: 2}"},
+		{`mapKeys({"a": 1}, fn(k) { [k, k] })`, "{[a, a]: 1}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`mapKeys({"a": 1}, fn(k) { [k, {}] })`, "unusable as hash key: ARRAY"},
+		{`mapValues({"a": 1}, fn(v) { v + "x" })`, "type mismatch: INTEGER + STRING"},
+		{`mapKeys({"a": 1}, fn(k) { k - 1 })`, "type mismatch: STRING - INTEGER"},
+		{"mapValues([1], len)", "first argument to `mapValues` must be HASH, got=ARRAY"},
+		{`mapKeys({}, 1)`, "second argument to `mapKeys` must be a function, got=INTEGER"},
+		{"mapKeys({})", "wrong number of arguments: got=1, want=2"},
+	}
+
+	for _, tt := range errorTests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
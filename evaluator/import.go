@@ -0,0 +1,88 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anukuljoshi/monkey/ast"
+	"github.com/anukuljoshi/monkey/lexer"
+	"github.com/anukuljoshi/monkey/object"
+	"github.com/anukuljoshi/monkey/parser"
+)
+
+// ModuleResolver loads the source code of the module named by an import
+type ModuleResolver interface {
+	Resolve(path string) (string, error)
+}
+
+// FileResolver reads modules from the filesystem. Relative import paths
+// are resolved against Root, or the working directory when Root is empty.
+type FileResolver struct {
+	Root string
+}
+
+func (r FileResolver) Resolve(path string) (string, error) {
+	if r.Root != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(r.Root, path)
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(source), nil
+}
+
+// MapResolver serves module sources from memory, keyed by import path. It
+// is handy for tests and for hosts that embed their scripts.
+type MapResolver map[string]string
+
+func (r MapResolver) Resolve(path string) (string, error) {
+	source, ok := r[path]
+	if !ok {
+		return "", fmt.Errorf("module not found")
+	}
+	return source, nil
+}
+
+// evalImportStatement runs the imported module in the importing
+// environment, so its top-level let bindings become visible there
+func evalImportStatement(
+	node *ast.ImportStatement,
+	env *object.Environment,
+) object.Object {
+	path := node.Path.Value
+	state := attachState(env)
+
+	if state.importing[path] {
+		return newError("cyclic import of %s", path)
+	}
+
+	var resolver ModuleResolver = FileResolver{}
+	if state.options.Resolver != nil {
+		resolver = state.options.Resolver
+	}
+	source, err := resolver.Resolve(path)
+	if err != nil {
+		return newError("cannot import %s: %s", path, err)
+	}
+
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("cannot import %s: %s", path, strings.Join(p.Errors(), "; "))
+	}
+
+	if state.importing == nil {
+		state.importing = make(map[string]bool)
+	}
+	state.importing[path] = true
+	defer delete(state.importing, path)
+
+	result := Eval(program, env)
+	if isError(result) {
+		return result
+	}
+	return NULL
+}
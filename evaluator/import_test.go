@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+func TestImportStatements(t *testing.T) {
+	modules := MapResolver{
+		"math.monkey": `
+			let square = fn(x) { x * x };
+			let two = 2;
+		`,
+		"uses_math.monkey": `
+			import "math.monkey";
+			let four = square(two);
+		`,
+		"cycle_a.monkey": `import "cycle_b.monkey";`,
+		"cycle_b.monkey": `import "cycle_a.monkey";`,
+		"self.monkey":    `import "self.monkey";`,
+		"broken.monkey":  `let x 5;`,
+		"failing.monkey": `let x = 1 + true;`,
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`import "math.monkey"; square(3);`, 9},
+		{`import "math.monkey"; two;`, 2},
+		{`import "uses_math.monkey"; four + square(1);`, 5},
+		{`import "math.monkey"; import "math.monkey"; two;`, 2},
+		{`let f = fn() { import "math.monkey"; square(5) }; f();`, 25},
+		{`import "missing.monkey";`, "cannot import missing.monkey: module not found"},
+		{`import "cycle_a.monkey";`, "cyclic import of cycle_a.monkey"},
+		{`import "self.monkey";`, "cyclic import of self.monkey"},
+		{`import "broken.monkey";`, "cannot import broken.monkey: expected next token to be =, got INT instead"},
+		{`import "failing.monkey";`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithOptions(tt.input, EvalOptions{Resolver: modules})
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestImportReadsFilesByDefault(t *testing.T) {
+	evaluated := testEval(`import "does/not/exist.monkey";`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned, got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := "cannot import does/not/exist.monkey: open does/not/exist.monkey: no such file or directory"
+	if errObj.Message != expected {
+		t.Errorf("errObj.Message: expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"lib/strings.monkey": `let greet = fn(name) { "hello " + name };`,
+		"main.monkey":        `import "lib/strings.monkey"; let message = greet("monkey");`,
+	}
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolver := FileResolver{Root: root}
+	source, err := resolver.Resolve("lib/strings.monkey")
+	if err != nil {
+		t.Fatalf("resolver.Resolve returned error: %s", err)
+	}
+	if source != files["lib/strings.monkey"] {
+		t.Errorf("source: expected=%q, got=%q", files["lib/strings.monkey"], source)
+	}
+
+	evaluated := testEvalWithOptions(
+		`import "main.monkey"; message`,
+		EvalOptions{Resolver: resolver},
+	)
+	testStringObject(t, evaluated, "hello monkey")
+
+	if _, err := resolver.Resolve("missing.monkey"); err == nil {
+		t.Errorf("expected an error resolving a missing file")
+	}
+}
+
+func TestMapResolver(t *testing.T) {
+	resolver := MapResolver{"a": "let a = 1;"}
+
+	source, err := resolver.Resolve("a")
+	if err != nil || source != "let a = 1;" {
+		t.Errorf("resolver.Resolve(%q): got=(%q, %v)", "a", source, err)
+	}
+	if _, err := resolver.Resolve("b"); err == nil {
+		t.Errorf("expected an error resolving an unknown module")
+	}
+}
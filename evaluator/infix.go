@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+// AnyOperator registers an infix handler for every operator on a pair of
+// operand types. A handler for the exact operator is preferred over it.
+const AnyOperator = ""
+
+// InfixOperatorFunc evaluates left operator right. Handlers registered for
+// AnyOperator report operators they do not support as an error.
+type InfixOperatorFunc func(operator string, left, right object.Object) object.Object
+
+type infixKey struct {
+	left     object.ObjectType
+	operator string
+	right    object.ObjectType
+}
+
+// infixOperators holds the handlers evalInfixExpression dispatches to.
+// Operand pairs without a handler fall back to comparing by identity for
+// == and != and are an error otherwise.
+var infixOperators = map[infixKey]InfixOperatorFunc{}
+
+func init() {
+	numbers := []object.ObjectType{object.INTEGER_OBJ, object.BIGINT_OBJ, object.FLOAT_OBJ}
+	for _, left := range numbers {
+		for _, right := range numbers {
+			switch {
+			case left == object.INTEGER_OBJ && right == object.INTEGER_OBJ:
+				registerInfix(left, AnyOperator, right, evalIntegerInfixExpression)
+			case left == object.FLOAT_OBJ || right == object.FLOAT_OBJ:
+				registerInfix(left, AnyOperator, right, evalFloatInfixExpression)
+			default:
+				registerInfix(left, AnyOperator, right, evalBigIntInfixExpression)
+			}
+		}
+	}
+
+	registerInfix(object.STRING_OBJ, AnyOperator, object.STRING_OBJ, evalStringInfixExpression)
+	registerInfix(object.STRING_OBJ, "*", object.INTEGER_OBJ,
+		func(_ string, left, right object.Object) object.Object {
+			return evalStringRepetition(left, right)
+		})
+	registerInfix(object.INTEGER_OBJ, "*", object.STRING_OBJ,
+		func(_ string, left, right object.Object) object.Object {
+			return evalStringRepetition(right, left)
+		})
+
+	registerInfix(object.ARRAY_OBJ, "+", object.ARRAY_OBJ,
+		func(_ string, left, right object.Object) object.Object {
+			return evalArrayConcatenation(left, right)
+		})
+	registerInfix(object.ARRAY_OBJ, "*", object.INTEGER_OBJ,
+		func(_ string, left, right object.Object) object.Object {
+			return evalArrayRepetition(left, right)
+		})
+	registerInfix(object.INTEGER_OBJ, "*", object.ARRAY_OBJ,
+		func(_ string, left, right object.Object) object.Object {
+			return evalArrayRepetition(right, left)
+		})
+}
+
+func registerInfix(
+	left object.ObjectType,
+	operator string,
+	right object.ObjectType,
+	fn InfixOperatorFunc,
+) {
+	infixOperators[infixKey{left, operator, right}] = fn
+}
+
+func lookupInfix(operator string, left, right object.Object) (InfixOperatorFunc, bool) {
+	if fn, ok := infixOperators[infixKey{left.Type(), operator, right.Type()}]; ok {
+		return fn, true
+	}
+	fn, ok := infixOperators[infixKey{left.Type(), AnyOperator, right.Type()}]
+	return fn, ok
+}
+
+// RegisterInfixOperator makes scripts evaluate left operator right with fn
+// for operands of the given types. As with RegisterBuiltin the standard
+// operators cannot be replaced: registering a pair of types and an operator
+// that is already handled, by its own handler or one for AnyOperator, is an
+// error. It must not be called while scripts run.
+func RegisterInfixOperator(
+	left object.ObjectType,
+	operator string,
+	right object.ObjectType,
+	fn InfixOperatorFunc,
+) error {
+	_, exact := infixOperators[infixKey{left, operator, right}]
+	_, wildcard := infixOperators[infixKey{left, AnyOperator, right}]
+	if exact || wildcard {
+		if operator == AnyOperator {
+			return fmt.Errorf("infix operators on %s and %s are already defined",
+				left, right)
+		}
+		return fmt.Errorf("infix operator %s %s %s is already defined",
+			left, operator, right)
+	}
+	registerInfix(left, operator, right, fn)
+	return nil
+}
@@ -0,0 +1,93 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+func TestRegisterInfixOperator(t *testing.T) {
+	mergeHashes := func(_ string, left, right object.Object) object.Object {
+		return builtins["merge"].Fn(left, right)
+	}
+	err := RegisterInfixOperator(object.HASH_OBJ, "+", object.HASH_OBJ, mergeHashes)
+	if err != nil {
+		t.Fatalf("RegisterInfixOperator returned error: %s", err)
+	}
+	t.Cleanup(func() {
+		delete(infixOperators, infixKey{object.HASH_OBJ, "+", object.HASH_OBJ})
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": 1} + {"b": 2}`, "{a: 1, b: 2}"},
+		{`{"a": 1} + {"a": 2} + {"c": 3}`, "{a: 2, c: 3}"},
+		// other operators on the same types are untouched
+		{`let h = {}; h == h`, "true"},
+		{`{} - {}`, "ERROR: unknown operator: HASH - HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestRegisterInfixOperatorForAnyOperator(t *testing.T) {
+	// booleans compare as numbers, false < true
+	asNumber := func(b object.Object) object.Object {
+		if b == TRUE {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	}
+	err := RegisterInfixOperator(object.BOOLEAN_OBJ, AnyOperator, object.BOOLEAN_OBJ,
+		func(operator string, left, right object.Object) object.Object {
+			switch operator {
+			case "<", ">", "<=", ">=":
+				return evalInfixExpression(operator, asNumber(left), asNumber(right))
+			}
+			return newError("unknown operator: %s %s %s",
+				left.Type(), operator, right.Type())
+		})
+	if err != nil {
+		t.Fatalf("RegisterInfixOperator returned error: %s", err)
+	}
+	t.Cleanup(func() {
+		delete(infixOperators, infixKey{object.BOOLEAN_OBJ, AnyOperator, object.BOOLEAN_OBJ})
+	})
+
+	testBooleanObject(t, testEval("false < true"), true)
+	testBooleanObject(t, testEval("true <= false"), false)
+	testErrorObject(t, testEval("true + true"), "unknown operator: BOOLEAN + BOOLEAN")
+}
+
+func TestRegisterInfixOperatorCollision(t *testing.T) {
+	noop := func(_ string, left, right object.Object) object.Object { return NULL }
+	tests := []struct {
+		left     object.ObjectType
+		operator string
+		right    object.ObjectType
+		expected string
+	}{
+		{object.INTEGER_OBJ, "+", object.INTEGER_OBJ, "infix operator INTEGER + INTEGER is already defined"},
+		{object.ARRAY_OBJ, "+", object.ARRAY_OBJ, "infix operator ARRAY + ARRAY is already defined"},
+		{object.STRING_OBJ, AnyOperator, object.STRING_OBJ, "infix operators on STRING and STRING are already defined"},
+	}
+
+	for _, tt := range tests {
+		err := RegisterInfixOperator(tt.left, tt.operator, tt.right, noop)
+		if err == nil {
+			t.Errorf("expected an error registering %s %s %s", tt.left, tt.operator, tt.right)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("err.Error(): expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+	testIntegerObject(t, testEval("1 + 2"), 3)
+}
@@ -0,0 +1,175 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+// writeJSON encodes obj as JSON. Hash keys must be strings in JSON, so
+// integer and boolean keys are written in their Inspect() form, and two
+// keys with the same form are an error. Members keep the hash's order.
+func writeJSON(out *bytes.Buffer, obj object.Object) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		out.WriteString(obj.Inspect())
+	case *object.BigInt:
+		out.WriteString(obj.Inspect())
+	case *object.Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return newError("value not serializable to JSON: %s", obj.Inspect())
+		}
+		out.WriteString(obj.Inspect())
+	case *object.Boolean:
+		out.WriteString(obj.Inspect())
+	case *object.Null, *object.Undefined:
+		out.WriteString("null")
+	case *object.String:
+		writeJSONString(out, obj.Value)
+	case *object.Array:
+		out.WriteString("[")
+		for i, e := range obj.Elements {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			if err := writeJSON(out, e); err != nil {
+				return err
+			}
+		}
+		out.WriteString("]")
+	case *object.Hash:
+		seen := make(map[string]bool, len(obj.Pairs))
+		out.WriteString("{")
+		for i, pair := range obj.OrderedPairs() {
+			key := jsonKey(pair.Key)
+			if seen[key] {
+				return newError("duplicate key in JSON object: %q", key)
+			}
+			seen[key] = true
+
+			if i > 0 {
+				out.WriteString(",")
+			}
+			writeJSONString(out, key)
+			out.WriteString(":")
+			if err := writeJSON(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteString("}")
+	default:
+		return newError("value not serializable to JSON: %s", obj.Type())
+	}
+	return nil
+}
+
+func jsonKey(key object.Object) string {
+	if str, ok := key.(*object.String); ok {
+		return str.Value
+	}
+	return key.Inspect()
+}
+
+func writeJSONString(out *bytes.Buffer, value string) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	out.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
+
+// parseJSON decodes a JSON document into the equivalent Monkey objects
+func parseJSON(input string) object.Object {
+	decoder := json.NewDecoder(strings.NewReader(input))
+
+	// checked as a whole first, so the value is known to be well formed
+	// while it is read token by token below
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return newError("malformed JSON: %s", err)
+	}
+	if decoder.More() {
+		return newError("malformed JSON: unexpected data after top-level value")
+	}
+
+	decoder = json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decodeJSON(decoder)
+}
+
+// decodeJSON reads the next value token by token, so the members of an
+// object are added to the hash in the order the document lists them
+func decodeJSON(decoder *json.Decoder) object.Object {
+	tok, err := decoder.Token()
+	if err != nil {
+		return newError("malformed JSON: %s", err)
+	}
+
+	switch tok := tok.(type) {
+	case nil:
+		return NULL
+	case bool:
+		return object.NativeBool(tok)
+	case string:
+		return &object.String{Value: tok}
+	case json.Number:
+		return fromJSONNumber(tok)
+	case json.Delim:
+		var result object.Object
+		if tok == '[' {
+			elements := []object.Object{}
+			for decoder.More() {
+				element := decodeJSON(decoder)
+				if isError(element) {
+					return element
+				}
+				elements = append(elements, element)
+			}
+			result = &object.Array{Elements: elements}
+		} else {
+			hash := &object.Hash{}
+			for decoder.More() {
+				key := decodeJSON(decoder)
+				if isError(key) {
+					return key
+				}
+				value := decodeJSON(decoder)
+				if isError(value) {
+					return value
+				}
+				hashKey, _ := object.HashKeyOf(key)
+				hash.Set(hashKey, object.HashPair{Key: key, Value: value})
+			}
+			result = hash
+		}
+		// the closing ] or }
+		if _, err := decoder.Token(); err != nil {
+			return newError("malformed JSON: %s", err)
+		}
+		return result
+	default:
+		return newError("malformed JSON: unexpected value %v", tok)
+	}
+}
+
+// numbers without a fraction or exponent become integers, everything
+// else becomes a float
+func fromJSONNumber(number json.Number) object.Object {
+	if i, err := number.Int64(); err == nil {
+		return &object.Integer{Value: i}
+	}
+	if !strings.ContainsAny(number.String(), ".eE") {
+		if i, ok := new(big.Int).SetString(number.String(), 10); ok {
+			return &object.BigInt{Value: i}
+		}
+	}
+	f, err := number.Float64()
+	if err != nil {
+		return newError("malformed JSON: %s", err)
+	}
+	return &object.Float{Value: f}
+}
@@ -0,0 +1,178 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/anukuljoshi/monkey/object"
+)
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`toJSON(1)`, `1`},
+		{`toJSON(-5)`, `-5`},
+		{`toJSON(99999999999999999999)`, `99999999999999999999`},
+		{`toJSON(true)`, `true`},
+		{`toJSON(if (false) { 1 })`, `null`},
+		{"toJSON(`a \"quoted\" <tag>`)", `"a \"quoted\" <tag>"`},
+		{"toJSON(`line\nbreak`)", `"line\nbreak"`},
+		{`toJSON([])`, `[]`},
+		{`toJSON({})`, `{}`},
+		{`toJSON([1, "two", [true, []]])`, `[1,"two",[true,[]]]`},
+		// members keep the order of the hash
+		{`toJSON({"b": true, "a": [1, 2]})`, `{"b":true,"a":[1,2]}`},
+		{`let h = {"z": 1}; h["a"] = 2; h["z"] = 3; toJSON(h)`, `{"z":3,"a":2}`},
+		{`toJSON({"outer": {"inner": [{"x": 1}]}})`, `{"outer":{"inner":[{"x":1}]}}`},
+		// non-string keys are stringified
+		{`toJSON({1: "one", true: "yes"})`, `{"1":"one","true":"yes"}`},
+		{`toJSON({1: "a", "1": "b"})`, `duplicate key in JSON object: "1"`},
+		{`toJSON({true: 1, "true": 2})`, `duplicate key in JSON object: "true"`},
+		{`toJSON([{"x": {2: 1, "2": 2}}])`, `duplicate key in JSON object: "2"`},
+		// errors
+		{`toJSON(fn(x) { x })`, "value not serializable to JSON: FUNCTION"},
+		{`toJSON([1, len])`, "value not serializable to JSON: BUILTIN"},
+		{`toJSON({"f": fn() { 1 }})`, "value not serializable to JSON: FUNCTION"},
+		{`toJSON()`, "wrong number of arguments: got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case string:
+			if isError(evaluated) {
+				testErrorObject(t, evaluated, expected)
+			} else {
+				testStringObject(t, evaluated, expected)
+			}
+		}
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fromJSON(`1`)", 1},
+		{"fromJSON(`-42`)", -42},
+		{"fromJSON(`true`)", true},
+		{"fromJSON(`null`)", nil},
+		{"fromJSON(`\"text\"`)", "text"},
+		{"fromJSON(`{\"a\": 1, \"b\": [2, 3]}`)[\"a\"]", 1},
+		{"fromJSON(`{\"a\": 1, \"b\": [2, 3]}`)[\"b\"][1]", 3},
+		{"len(fromJSON(`[1, [2, 3], {}]`))", 3},
+		{"fromJSON(`{\"nested\": {\"deep\": [false]}}`)[\"nested\"][\"deep\"][0]", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestFromJSONBooleansAreSingletons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"fromJSON(`true`) == true", true},
+		{"fromJSON(`false`) == false", true},
+		{"fromJSON(`[true]`)[0] == (1 < 2)", true},
+		{"fromJSON(`true`) == fromJSON(`true`)", true},
+		{"fromJSON(`true`) != isInt(1)", false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFromJSONNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		objType  object.ObjectType
+	}{
+		{"fromJSON(`7`)", "7", object.INTEGER_OBJ},
+		{"fromJSON(`1.5`)", "1.5", object.FLOAT_OBJ},
+		{"fromJSON(`2.0`)", "2.0", object.FLOAT_OBJ},
+		{"fromJSON(`1e3`)", "1000.0", object.FLOAT_OBJ},
+		{"fromJSON(`123456789012345678901234567890`)", "123456789012345678901234567890", object.BIGINT_OBJ},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() != tt.objType {
+			t.Errorf("%s: type expected=%s, got=%s", tt.input, tt.objType, evaluated.Type())
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fromJSON(`{\"a\": }`)", "malformed JSON: invalid character '}' looking for beginning of value"},
+		{"fromJSON(`[1, 2`)", "malformed JSON: unexpected EOF"},
+		{"fromJSON(`1 2`)", "malformed JSON: unexpected data after top-level value"},
+		{"fromJSON(1)", "argument to `fromJSON` must be STRING, got=INTEGER"},
+		{"fromJSON()", "wrong number of arguments: got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFromJSONMemberOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"keys(fromJSON(`{\"c\": 1, \"a\": 2, \"b\": 3}`))", "[c, a, b]"},
+		{"fromJSON(`{\"z\": {\"y\": 1, \"x\": 2}}`)", "{z: {y: 1, x: 2}}"},
+		// a repeated member keeps its first position and takes the last value
+		{"fromJSON(`{\"a\": 1, \"b\": 2, \"a\": 3}`)", "{a: 3, b: 2}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []string{
+		`1`,
+		`1.5`,
+		`"text"`,
+		`[1,2.5,"three",true,null]`,
+		`{"a":1,"b":[2,3],"c":{"d":null}}`,
+		`{"b":1,"a":{"z":null,"y":[]}}`,
+		`[]`,
+		`{}`,
+	}
+
+	for _, input := range tests {
+		evaluated := testEval("toJSON(fromJSON(`" + input + "`))")
+		testStringObject(t, evaluated, input)
+	}
+}
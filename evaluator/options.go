@@ -0,0 +1,224 @@
+package evaluator
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/anukuljoshi/monkey/ast"
+	"github.com/anukuljoshi/monkey/object"
+)
+
+// DefaultMaxCallDepth is the call depth limit used when EvalOptions does not
+// set one. It keeps runaway recursion well clear of the Go stack limit.
+const DefaultMaxCallDepth = 10000
+
+// EvalOptions configures an evaluation started with EvalWithOptions
+type EvalOptions struct {
+	// MaxSteps caps the number of nodes evaluated, zero means no limit
+	MaxSteps int
+	// MaxCallDepth caps the number of nested function calls, zero means
+	// DefaultMaxCallDepth
+	MaxCallDepth int
+	// Resolver loads the source of imported modules, a FileResolver rooted
+	// at the working directory is used when it is nil
+	Resolver ModuleResolver
+	// DisallowBuiltinShadowing makes binding the name of a builtin an
+	// error instead of hiding the builtin, whether by let, as the name or a
+	// parameter of a function, or in a catch clause
+	DisallowBuiltinShadowing bool
+	// Output receives what print writes, os.Stdout is used when it is nil
+	Output io.Writer
+	// CoerceStrings makes + with one string operand convert the other one
+	// with Inspect and concatenate, instead of a type mismatch
+	CoerceStrings bool
+	// Input is where input reads lines from, os.Stdin is used when it is
+	// nil. A *bufio.Reader is read from directly, so the host can share it
+	// without input buffering lines ahead of it.
+	Input io.Reader
+}
+
+// evalState is attached to an environment and shared by every environment
+// enclosed by it, so it follows evaluation into function calls
+type evalState struct {
+	options EvalOptions
+	steps   int
+	depth   int // function calls currently active
+	ctx     context.Context
+	// modules currently being imported, to detect import cycles
+	importing map[string]bool
+	// source for the random builtins, created on first use unless seeded
+	random *rand.Rand
+	// buffers options.Input between calls to input, created on first use
+	input *bufio.Reader
+}
+
+// EvalWithOptions evaluates node like Eval but under the given options. The
+// options stay attached to env, so later calls to Eval with env (or with a
+// function closed over it) keep honouring them; the step count is reset on
+// every call to EvalWithOptions.
+func EvalWithOptions(
+	node ast.Node,
+	env *object.Environment,
+	options EvalOptions,
+) object.Object {
+	state := attachState(env)
+	state.options = options
+	state.steps = 0
+	state.input = nil
+	return Eval(node, env)
+}
+
+// EvalWithContext evaluates node like Eval, but stops with an error as soon
+// as ctx is cancelled or its deadline passes. Unlike options, ctx is only
+// consulted for the duration of this call.
+func EvalWithContext(
+	ctx context.Context,
+	node ast.Node,
+	env *object.Environment,
+) object.Object {
+	state := attachState(env)
+	previous := state.ctx
+	state.ctx = ctx
+	defer func() { state.ctx = previous }()
+	return Eval(node, env)
+}
+
+// attachState returns the state env sees, creating it on the outermost
+// environment when there is none so that every scope shares it
+func attachState(env *object.Environment) *evalState {
+	state := stateOf(env)
+	if state == nil {
+		state = &evalState{}
+		env.Root().SetState(state)
+	}
+	return state
+}
+
+func stateOf(env *object.Environment) *evalState {
+	state, _ := env.State().(*evalState)
+	return state
+}
+
+// enterCall is called before a function body is evaluated and must be paired
+// with leaveCall
+func (s *evalState) enterCall() *object.Error {
+	limit := s.options.MaxCallDepth
+	if limit == 0 {
+		limit = DefaultMaxCallDepth
+	}
+	if s.depth >= limit {
+		return newError("maximum call depth exceeded")
+	}
+	s.depth += 1
+	return nil
+}
+
+// outputOf returns the writer print writes to under env
+func outputOf(env *object.Environment) io.Writer {
+	if state := stateOf(env); state != nil && state.options.Output != nil {
+		return state.options.Output
+	}
+	return os.Stdout
+}
+
+// inputOf returns the reader input reads lines from under env
+func inputOf(env *object.Environment) *bufio.Reader {
+	state := attachState(env)
+	if state.input == nil {
+		var in io.Reader = os.Stdin
+		if state.options.Input != nil {
+			in = state.options.Input
+		}
+		if buffered, ok := in.(*bufio.Reader); ok {
+			state.input = buffered
+		} else {
+			state.input = bufio.NewReader(in)
+		}
+	}
+	return state.input
+}
+
+// coerceStrings concatenates left and right when operator is + and the
+// options allow a string to be added to a value of another type. An infix
+// operator registered for the pair of types takes precedence.
+func coerceStrings(
+	operator string,
+	left, right object.Object,
+	env *object.Environment,
+) (object.Object, bool) {
+	if operator != "+" || left.Type() == right.Type() {
+		return nil, false
+	}
+	if left.Type() != object.STRING_OBJ && right.Type() != object.STRING_OBJ {
+		return nil, false
+	}
+	state := stateOf(env)
+	if state == nil || !state.options.CoerceStrings {
+		return nil, false
+	}
+	if _, ok := lookupInfix(operator, left, right); ok {
+		return nil, false
+	}
+	return &object.String{Value: left.Inspect() + right.Inspect()}, true
+}
+
+// checkBinding reports an error when the options forbid binding name
+func checkBinding(name string, env *object.Environment) *object.Error {
+	state := stateOf(env)
+	if state == nil || !state.options.DisallowBuiltinShadowing {
+		return nil
+	}
+	if _, ok := builtins[name]; ok {
+		return newError("cannot redefine builtin %s", name)
+	}
+	return nil
+}
+
+// checkFunctionBindings applies checkBinding to the name and parameters of
+// a function literal
+func checkFunctionBindings(fn *ast.FunctionLiteral, env *object.Environment) *object.Error {
+	if fn.Name != nil {
+		if err := checkBinding(fn.Name.Value, env); err != nil {
+			return err
+		}
+	}
+	for _, param := range fn.Parameters {
+		if err := checkBinding(param.Value, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *evalState) leaveCall() {
+	s.depth -= 1
+}
+
+func (s *evalState) rand() *rand.Rand {
+	if s.random == nil {
+		s.seed(time.Now().UnixNano())
+	}
+	return s.random
+}
+
+func (s *evalState) seed(seed int64) {
+	s.random = rand.New(rand.NewSource(seed))
+}
+
+// step is called before every node is evaluated
+func (s *evalState) step() *object.Error {
+	s.steps += 1
+	if s.options.MaxSteps > 0 && s.steps > s.options.MaxSteps {
+		return newError("evaluation step limit exceeded")
+	}
+	if s.ctx != nil {
+		if err := s.ctx.Err(); err != nil {
+			return newError("evaluation cancelled: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package evaluator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anukuljoshi/monkey/lexer"
+	"github.com/anukuljoshi/monkey/object"
+	"github.com/anukuljoshi/monkey/parser"
+)
+
+func testEvalWithOptions(input string, options EvalOptions) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	return EvalWithOptions(program, env, options)
+}
+
+func testErrorObject(t *testing.T, obj object.Object, expected string) bool {
+	errObj, ok := obj.(*object.Error)
+	if !ok {
+		t.Errorf("no error object returned, got=%T (%+v)", obj, obj)
+		return false
+	}
+	if errObj.Message != expected {
+		t.Errorf("errObj.Message: expected=%q, got=%q", expected, errObj.Message)
+		return false
+	}
+	return true
+}
+
+func TestMaxSteps(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxSteps int
+		expected interface{}
+	}{
+		{"while (true) { }", 1000, "evaluation step limit exceeded"},
+		{"let f = fn() { f() }; f();", 1000, "evaluation step limit exceeded"},
+		{"let i = 0; while (i < 10) { i++ }; i;", 1000, 10},
+		{"let i = 0; while (i < 10) { i++ }; i;", 0, 10},
+		{"1 + 2", 5, 3},
+		{"1 + 2", 4, "evaluation step limit exceeded"},
+		// the handler is evaluated under the same limit, so it cannot swallow it
+		{"while (true) { try { 1 } catch (e) { 0 } }", 1000, "evaluation step limit exceeded"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithOptions(tt.input, EvalOptions{MaxSteps: tt.maxSteps})
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestMaxStepsResetPerEvaluation(t *testing.T) {
+	env := object.NewEnvironment()
+	options := EvalOptions{MaxSteps: 50}
+	program := parser.New(lexer.New("let i = 0; while (i < 3) { i++ }; i;")).ParseProgram()
+
+	for i := 0; i < 5; i++ {
+		testIntegerObject(t, EvalWithOptions(program, env, options), 3)
+	}
+}
+
+func TestMaxStepsInEarlierClosure(t *testing.T) {
+	env := object.NewEnvironment()
+	// g closes over an environment created before any options were attached
+	Eval(parser.New(lexer.New(
+		"let mk = fn() { fn() { while (true) { } } }; let g = mk();",
+	)).ParseProgram(), env)
+
+	program := parser.New(lexer.New("g()")).ParseProgram()
+	done := make(chan object.Object)
+	go func() {
+		done <- EvalWithOptions(program, env, EvalOptions{MaxSteps: 1000})
+	}()
+
+	select {
+	case evaluated := <-done:
+		testErrorObject(t, evaluated, "evaluation step limit exceeded")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("step limit did not apply to a closure created before it was set")
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	countdown := `
+let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } };
+countdown(%d)`
+	tests := []struct {
+		input    string
+		maxDepth int
+		expected interface{}
+	}{
+		{fmt.Sprintf(countdown, 50), 100, 0},
+		{fmt.Sprintf(countdown, 99), 100, 0},
+		{fmt.Sprintf(countdown, 100), 100, "maximum call depth exceeded"},
+		{fmt.Sprintf(countdown, 5000), 0, 0},
+		{"let f = fn() { f() }; f();", 0, "maximum call depth exceeded"},
+		{"let f = fn(x) { 1 + f(x) }; f(1);", 10, "maximum call depth exceeded"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithOptions(tt.input, EvalOptions{MaxCallDepth: tt.maxDepth})
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+
+	// plain Eval applies the default limit, also to recursion started from
+	// a nested scope
+	for _, input := range []string{
+		"let f = fn() { f() }; f();",
+		"let f = fn(x) { f(x) }; for (let i = 0; i < 1; i++) { f(1) }",
+		`let f = fn(x) { f(x) }; try { 1 + "a" } catch (e) { f(1) }`,
+	} {
+		testErrorObject(t, testEval(input), "maximum call depth exceeded")
+	}
+}
+
+func TestCallDepthUnwindsAfterError(t *testing.T) {
+	env := object.NewEnvironment()
+	options := EvalOptions{MaxCallDepth: 20}
+	deep := parser.New(lexer.New("let f = fn(n) { f(n + 1) }; f(0);")).ParseProgram()
+	testErrorObject(t, EvalWithOptions(deep, env, options), "maximum call depth exceeded")
+
+	// the calls that failed no longer count against the limit
+	shallow := parser.New(lexer.New(
+		"let g = fn(n) { if (n == 0) { 0 } else { g(n - 1) } }; g(15);",
+	)).ParseProgram()
+	testIntegerObject(t, EvalWithOptions(shallow, env, options), 0)
+}
+
+func TestDisallowBuiltinShadowing(t *testing.T) {
+	tests := []struct {
+		input    string
+		disallow bool
+		expected string
+	}{
+		{"let len = 5; len", false, "5"},
+		{"let len = 5; len", true, "ERROR: cannot redefine builtin len"},
+		{"let print = fn(x) { x }; print(1)", true, "ERROR: cannot redefine builtin print"},
+		{`let f = fn() { let first = 1; first }; f()`, true, "ERROR: cannot redefine builtin first"},
+		// the value is not evaluated once the name is rejected
+		{"let len = missing", true, "ERROR: cannot redefine builtin len"},
+		{"let length = 5; len([1, 2]) + length", true, "7"},
+		{"let f = fn(len) { len }; f(3)", false, "3"},
+		{"let f = fn(len) { len }; f(3)", true, "ERROR: cannot redefine builtin len"},
+		{"let f = fn(a, first) { a }; f(1, 2)", true, "ERROR: cannot redefine builtin first"},
+		{"fn len() { 0 }()", true, "ERROR: cannot redefine builtin len"},
+		{"fn length() { 0 }()", true, "0"},
+		{`try { 1 + "a" } catch (len) { 0 }`, true, "ERROR: cannot redefine builtin len"},
+		{`try { 1 + "a" } catch (len) { 0 }`, false, "0"},
+		{`try { 1 + "a" } catch (e) { 0 }`, true, "0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithOptions(tt.input,
+			EvalOptions{DisallowBuiltinShadowing: tt.disallow})
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	// a rejected function is not hoisted either
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New("let len = fn() { 0 };")).ParseProgram()
+	EvalWithOptions(program, env, EvalOptions{DisallowBuiltinShadowing: true})
+	if _, ok := env.Get("len"); ok {
+		t.Errorf("len was bound in the environment")
+	}
+}
+
+func TestOutput(t *testing.T) {
+	var out bytes.Buffer
+	evaluated := testEvalWithOptions(`print("hello", 1 + 2); print([1], {"a": true}); print()`,
+		EvalOptions{Output: &out})
+	testNullObject(t, evaluated)
+
+	expected := "hello\n3\n[1]\n{a: true}\n"
+	if out.String() != expected {
+		t.Errorf("output: expected=%q, got=%q", expected, out.String())
+	}
+
+	// functions keep writing to the output of the environment they close over
+	out.Reset()
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(`let greet = fn(name) { print("hi " + name) }`)).ParseProgram()
+	EvalWithOptions(program, env, EvalOptions{Output: &out})
+	Eval(parser.New(lexer.New(`greet("monkey")`)).ParseProgram(), env)
+	if out.String() != "hi monkey\n" {
+		t.Errorf("output: expected=%q, got=%q", "hi monkey\n", out.String())
+	}
+}
+
+func TestInput(t *testing.T) {
+	var out bytes.Buffer
+	options := EvalOptions{
+		Input:  strings.NewReader("monkey\r\n42\nlast"),
+		Output: &out,
+	}
+	evaluated := testEvalWithOptions(
+		`[input("name? "), input(), input(), input(), input("again? ")]`, options)
+
+	expected := "[monkey, 42, last, null, null]"
+	if evaluated.Inspect() != expected {
+		t.Errorf("result: expected=%s, got=%s", expected, evaluated.Inspect())
+	}
+	if out.String() != "name? again? " {
+		t.Errorf("output: expected=%q, got=%q", "name? again? ", out.String())
+	}
+
+	// a buffered reader is shared with the host rather than read ahead
+	shared := bufio.NewReader(strings.NewReader("first\nsecond\n"))
+	testStringObject(t, testEvalWithOptions("input()", EvalOptions{Input: shared}), "first")
+	if rest, _ := shared.ReadString('\n'); rest != "second\n" {
+		t.Errorf("host read: expected=%q, got=%q", "second\n", rest)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"input(1)", "argument to `input` must be STRING, got=INTEGER"},
+		{`input("a", "b")`, "wrong number of arguments: got=2, want=0 or 1"},
+	}
+	for _, tt := range errorTests {
+		evaluated := testEvalWithOptions(tt.input, EvalOptions{Input: strings.NewReader("")})
+		testErrorObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestCoerceStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		strict   string
+		coercing string
+	}{
+		{`"count: " + 5`, "ERROR: type mismatch: STRING + INTEGER", "count: 5"},
+		{`5 + " items"`, "ERROR: type mismatch: INTEGER + STRING", "5 items"},
+		{`"pi is " + 3.5`, "ERROR: type mismatch: STRING + FLOAT", "pi is 3.5"},
+		{`"ok: " + true + ", " + [1, 2]`, "ERROR: type mismatch: STRING + BOOLEAN", "ok: true, [1, 2]"},
+		{`"a" + "b"`, "ab", "ab"},
+		{`1 + 2`, "3", "3"},
+		// only + is affected
+		{`"a" * 2`, "aa", "aa"},
+		{`"a" - 1`, "ERROR: type mismatch: STRING - INTEGER", "ERROR: type mismatch: STRING - INTEGER"},
+		{`let f = fn(n) { "n=" + n }; f(4)`, "ERROR: type mismatch: STRING + INTEGER", "n=4"},
+	}
+
+	for _, tt := range tests {
+		strict := testEvalWithOptions(tt.input, EvalOptions{})
+		if strict.Inspect() != tt.strict {
+			t.Errorf("strict %s: expected=%s, got=%s", tt.input, tt.strict, strict.Inspect())
+		}
+		coercing := testEvalWithOptions(tt.input, EvalOptions{CoerceStrings: true})
+		if coercing.Inspect() != tt.coercing {
+			t.Errorf("coercing %s: expected=%s, got=%s", tt.input, tt.coercing, coercing.Inspect())
+		}
+	}
+}
+
+func TestEvalWithContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	env := object.NewEnvironment()
+	env.Set("stop", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			cancel()
+			return NULL
+		},
+	})
+	program := parser.New(lexer.New(
+		"let i = 0; while (true) { i++; if (i == 100) { stop(); } }",
+	)).ParseProgram()
+
+	evaluated := EvalWithContext(ctx, program, env)
+	testErrorObject(t, evaluated, "evaluation cancelled: context canceled")
+
+	i, _ := env.Get("i")
+	testIntegerObject(t, i, 100)
+
+	// the cancelled context does not outlive the call
+	testIntegerObject(t, Eval(parser.New(lexer.New("i")).ParseProgram(), env), 100)
+}
+
+func TestEvalWithContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	program := parser.New(lexer.New("while (true) { }")).ParseProgram()
+
+	done := make(chan object.Object)
+	go func() {
+		done <- EvalWithContext(ctx, program, object.NewEnvironment())
+	}()
+
+	select {
+	case evaluated := <-done:
+		testErrorObject(t, evaluated, "evaluation cancelled: context deadline exceeded")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("evaluation did not stop after the context deadline")
+	}
+}
+
+func TestEvalWithContextInEarlierClosure(t *testing.T) {
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New(
+		"let mk = fn() { fn() { while (true) { } } }; let g = mk();",
+	)).ParseProgram(), env)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	program := parser.New(lexer.New("g()")).ParseProgram()
+	done := make(chan object.Object)
+	go func() {
+		done <- EvalWithContext(ctx, program, env)
+	}()
+
+	select {
+	case evaluated := <-done:
+		testErrorObject(t, evaluated, "evaluation cancelled: context deadline exceeded")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("context deadline did not reach a closure created before it was set")
+	}
+}
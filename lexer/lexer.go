@@ -7,17 +7,26 @@ type Lexer struct {
 	postition    int
 	readPosition int
 	ch           byte
+	line         int // line of ch
+	column       int // column of ch
 }
 
 func New(input string) *Lexer {
 	l := &Lexer{
 		input: input,
+		line:  1,
 	}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	// \n, \r\n and a lone \r all end a line
+	if l.ch == '\n' || l.ch == '\r' && l.peekChar() != '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -43,23 +52,63 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[postition:l.postition]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer, or a float when the digits are followed by a
+// decimal point and at least one more digit
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	postition := l.postition
+	tokenType := token.TokenType(token.INT)
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[postition:l.postition]
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.ch) {
+			return "malformed exponent in " + l.input[postition:l.postition], token.ILLEGAL
+		}
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+	return l.input[postition:l.postition], tokenType
 }
 
-func (l *Lexer) readString() string {
+func (l *Lexer) readString() (string, bool) {
 	postition := l.postition + 1
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		if l.ch == '"' {
+			return l.input[postition:l.postition], true
+		}
+		if l.ch == 0 {
+			return l.input[postition:l.postition], false
+		}
+	}
+}
+
+// readRawString reads a backtick delimited string verbatim, escapes are not
+// processed and newlines are kept as is
+func (l *Lexer) readRawString() (string, bool) {
+	postition := l.postition + 1
+	for {
+		l.readChar()
+		if l.ch == '`' {
+			return l.input[postition:l.postition], true
+		}
+		if l.ch == 0 {
+			return l.input[postition:l.postition], false
 		}
 	}
-	return l.input[postition:l.postition]
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -72,6 +121,7 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	line, column := l.line, l.column
 
 	switch l.ch {
 	case '=':
@@ -86,9 +136,27 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '+' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{
+				Type:    token.INC,
+				Literal: string(ch) + string(l.ch),
+			}
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '-' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{
+				Type:    token.DEC,
+				Literal: string(ch) + string(l.ch),
+			}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -100,14 +168,54 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.BANG, l.ch)
 		}
+	case '&':
+		if l.peekChar() == '&' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{
+				Type:    token.AND,
+				Literal: string(ch) + string(l.ch),
+			}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{
+				Type:    token.OR,
+				Literal: string(ch) + string(l.ch),
+			}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '*':
 		tok = newToken(token.ASTERISK, l.ch)
 	case '/':
 		tok = newToken(token.FSLASH, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{
+				Type:    token.LT_EQ,
+				Literal: string(ch) + string(l.ch),
+			}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{
+				Type:    token.GT_EQ,
+				Literal: string(ch) + string(l.ch),
+			}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
@@ -127,8 +235,21 @@ func (l *Lexer) NextToken() token.Token {
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case '"':
-		tok.Literal = l.readString()
-		tok.Type = token.STRING
+		if str, ok := l.readString(); ok {
+			tok.Literal = str
+			tok.Type = token.STRING
+		} else {
+			tok.Literal = "unterminated string"
+			tok.Type = token.ILLEGAL
+		}
+	case '`':
+		if str, ok := l.readRawString(); ok {
+			tok.Literal = str
+			tok.Type = token.RAW_STRING
+		} else {
+			tok.Literal = "unterminated raw string"
+			tok.Type = token.ILLEGAL
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -136,15 +257,30 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 	l.readChar()
+	tok.Line, tok.Column = line, column
 	return tok
 }
+
+// Tokens reads the rest of the input and returns its tokens, ending with
+// the EOF token.
+func (l *Lexer) Tokens() []token.Token {
+	tokens := []token.Token{}
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/anukuljoshi/monkey/token"
@@ -201,3 +202,333 @@ func TestNextToken(*testing.T) {
 		}
 	}
 }
+
+func TestIncrementDecrementTokens(t *testing.T) {
+	input := `i++; i--; i + +1; i - -1;`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "i"},
+		{token.INC, "++"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "i"},
+		{token.DEC, "--"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "i"},
+		{token.PLUS, "+"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "i"},
+		{token.MINUS, "-"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestComparisonTokens(t *testing.T) {
+	input := `a <= b >= c < d > e`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.LT, "<"},
+		{token.IDENT, "d"},
+		{token.GT, ">"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNotKeyword(t *testing.T) {
+	input := `not x; !y; nothing`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.NOT, "not"},
+		{token.IDENT, "x"},
+		{token.SEMICOLON, ";"},
+		{token.BANG, "!"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "nothing"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestLogicalOperatorTokens(t *testing.T) {
+	input := `a && b || c and d or e & |`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.AND, "and"},
+		{token.IDENT, "d"},
+		{token.OR, "or"},
+		{token.IDENT, "e"},
+		{token.ILLEGAL, "&"},
+		{token.ILLEGAL, "|"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestFloatTokens(t *testing.T) {
+	input := `3.14 0.5 10. 7`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "0.5"},
+		{token.INT, "10"},
+		{token.ILLEGAL, "."},
+		{token.INT, "7"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestExponentTokens(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"1.5e3", token.FLOAT, "1.5e3"},
+		{"2E-2", token.FLOAT, "2E-2"},
+		{"6e+23", token.FLOAT, "6e+23"},
+		{"1e10", token.FLOAT, "1e10"},
+		{"0.25E0", token.FLOAT, "0.25E0"},
+		{"1e", token.ILLEGAL, "malformed exponent in 1e"},
+		{"1.5e-", token.ILLEGAL, "malformed exponent in 1.5e-"},
+		{"3E+x", token.ILLEGAL, "malformed exponent in 3E+"},
+	}
+
+	for i, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestRawString(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"`hello`", token.RAW_STRING, "hello"},
+		{"``", token.RAW_STRING, ""},
+		{"`say \"hi\"`", token.RAW_STRING, `say "hi"`},
+		{"`C:\\path\\n`", token.RAW_STRING, `C:\path\n`},
+		{"`line one\nline two\n`", token.RAW_STRING, "line one\nline two\n"},
+		{"`{\"a\": [1, 2]}`", token.RAW_STRING, `{"a": [1, 2]}`},
+		{"`${x}`", token.RAW_STRING, "${x}"},
+		{"`never closed", token.ILLEGAL, "unterminated raw string"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("test[%d] - expected EOF after string, got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x + \"ab\";\n@"
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.IDENT, 2, 3},
+		{token.PLUS, 2, 5},
+		{token.STRING, 2, 7},
+		{token.SEMICOLON, 2, 11},
+		{token.ILLEGAL, 3, 1},
+		{token.EOF, 3, 2},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("test[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	l := New(`"never closed`)
+	tok := l.NextToken()
+	if tok.Type != token.ILLEGAL {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.ILLEGAL, tok.Type)
+	}
+	if tok.Literal != "unterminated string" {
+		t.Fatalf("literal wrong. expected=%q, got=%q",
+			"unterminated string", tok.Literal)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	expected := []token.Token{
+		{Type: token.LET, Literal: "let", Line: 1, Column: 1},
+		{Type: token.IDENT, Literal: "x", Line: 1, Column: 5},
+		{Type: token.ASSIGN, Literal: "=", Line: 1, Column: 7},
+		{Type: token.INT, Literal: "5", Line: 1, Column: 9},
+		{Type: token.SEMICOLON, Literal: ";", Line: 1, Column: 10},
+		{Type: token.IDENT, Literal: "x", Line: 2, Column: 1},
+		{Type: token.EOF, Literal: "", Line: 2, Column: 2},
+	}
+
+	tokens := New("let x = 5;\nx").Tokens()
+	if len(tokens) != len(expected) {
+		t.Fatalf("wrong number of tokens. expected=%d, got=%d (%v)",
+			len(expected), len(tokens), tokens)
+	}
+	for i, tok := range tokens {
+		if tok != expected[i] {
+			t.Errorf("tokens[%d] wrong. expected=%+v, got=%+v", i, expected[i], tok)
+		}
+	}
+
+	empty := New("").Tokens()
+	if len(empty) != 1 || empty[0].Type != token.EOF {
+		t.Errorf("expected only EOF for empty input, got=%v", empty)
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	lf := "let x = 5;\nlet y = x + 1;\n\nputs(y)\n"
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"CRLF", strings.ReplaceAll(lf, "\n", "\r\n")},
+		{"CR", strings.ReplaceAll(lf, "\n", "\r")},
+	}
+
+	expected := New(lf).Tokens()
+	for _, tt := range tests {
+		tokens := New(tt.input).Tokens()
+		if len(tokens) != len(expected) {
+			t.Errorf("%s: wrong number of tokens. expected=%d, got=%d",
+				tt.name, len(expected), len(tokens))
+			continue
+		}
+		for i, tok := range tokens {
+			if tok.Type != expected[i].Type || tok.Literal != expected[i].Literal {
+				t.Errorf("%s: tokens[%d] wrong. expected=%q %q, got=%q %q", tt.name, i,
+					expected[i].Type, expected[i].Literal, tok.Type, tok.Literal)
+			}
+			if tok.Line != expected[i].Line || tok.Column != expected[i].Column {
+				t.Errorf("%s: tokens[%d] position wrong. expected=%d:%d, got=%d:%d",
+					tt.name, i, expected[i].Line, expected[i].Column, tok.Line, tok.Column)
+			}
+		}
+	}
+}
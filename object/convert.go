@@ -0,0 +1,144 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// ErrNotConvertible is returned, wrapped, for values that have no
+// counterpart on the other side, such as functions and errors
+var ErrNotConvertible = errors.New("not convertible")
+
+// ToGo converts obj to a plain Go value: int64, *big.Int, float64, string,
+// bool, nil for null, []interface{} for arrays and map[string]interface{}
+// for hashes. Hashes must only have string keys.
+func ToGo(obj Object) (interface{}, error) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return obj.Value, nil
+	case *BigInt:
+		return new(big.Int).Set(obj.Value), nil
+	case *Float:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+	case *Boolean:
+		return obj.Value, nil
+	case *Null, *Undefined:
+		return nil, nil
+	case *Array:
+		values := make([]interface{}, len(obj.Elements))
+		for i, e := range obj.Elements {
+			value, err := ToGo(e)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case *Hash:
+		values := make(map[string]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key, ok := pair.Key.(*String)
+			if !ok {
+				return nil, fmt.Errorf("%w: hash key of type %s", ErrNotConvertible, pair.Key.Type())
+			}
+			value, err := ToGo(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			values[key.Value] = value
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotConvertible, obj.Type())
+	}
+}
+
+// FromGo converts a Go value built from the types ToGo returns, or other
+// integer and float types, into the equivalent object. Objects are returned
+// as they are. Map keys are added to the hash in sorted order. Integers
+// become a BigInt only when they do not fit in an Integer, as in scripts.
+func FromGo(v interface{}) (Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return NULL, nil
+	case Object:
+		return v, nil
+	case bool:
+		return NativeBool(v), nil
+	case string:
+		return &String{Value: v}, nil
+	case int:
+		return &Integer{Value: int64(v)}, nil
+	case int8:
+		return &Integer{Value: int64(v)}, nil
+	case int16:
+		return &Integer{Value: int64(v)}, nil
+	case int32:
+		return &Integer{Value: int64(v)}, nil
+	case int64:
+		return &Integer{Value: v}, nil
+	case uint:
+		return fromUint64(uint64(v)), nil
+	case uint8:
+		return fromUint64(uint64(v)), nil
+	case uint16:
+		return fromUint64(uint64(v)), nil
+	case uint32:
+		return fromUint64(uint64(v)), nil
+	case uint64:
+		return fromUint64(v), nil
+	case *big.Int:
+		if v == nil {
+			return NULL, nil
+		}
+		if v.IsInt64() {
+			return &Integer{Value: v.Int64()}, nil
+		}
+		return &BigInt{Value: new(big.Int).Set(v)}, nil
+	case float32:
+		return &Float{Value: float64(v)}, nil
+	case float64:
+		return &Float{Value: v}, nil
+	case []interface{}:
+		elements := make([]Object, len(v))
+		for i, e := range v {
+			element, err := FromGo(e)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return &Array{Elements: elements}, nil
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for k := range v {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		hash := &Hash{}
+		for _, k := range names {
+			value, err := FromGo(v[k])
+			if err != nil {
+				return nil, err
+			}
+			key := &String{Value: k}
+			hash.Set(key.HashKey(), HashPair{Key: key, Value: value})
+		}
+		return hash, nil
+	default:
+		return nil, fmt.Errorf("%w: Go value of type %T", ErrNotConvertible, v)
+	}
+}
+
+func fromUint64(v uint64) Object {
+	if v > math.MaxInt64 {
+		return &BigInt{Value: new(big.Int).SetUint64(v)}
+	}
+	return &Integer{Value: int64(v)}
+}
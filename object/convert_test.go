@@ -0,0 +1,127 @@
+package object
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestGoRoundTrip(t *testing.T) {
+	huge, _ := new(big.Int).SetString("99999999999999999999", 10)
+	values := []interface{}{
+		int64(5),
+		"text",
+		true,
+		1.5,
+		nil,
+		huge,
+		[]interface{}{},
+		[]interface{}{int64(1), []interface{}{"a", []interface{}{false}}},
+		map[string]interface{}{},
+		map[string]interface{}{
+			"name": "monkey",
+			"tags": []interface{}{"a", map[string]interface{}{"deep": int64(2)}},
+			"meta": map[string]interface{}{"ok": true, "none": nil},
+		},
+	}
+
+	for _, value := range values {
+		obj, err := FromGo(value)
+		if err != nil {
+			t.Fatalf("FromGo(%v) returned error: %s", value, err)
+		}
+		back, err := ToGo(obj)
+		if err != nil {
+			t.Fatalf("ToGo(%s) returned error: %s", obj.Inspect(), err)
+		}
+		if !reflect.DeepEqual(back, value) {
+			t.Errorf("round trip of %#v: got=%#v", value, back)
+		}
+	}
+}
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{int(3), "3"},
+		{int32(-3), "-3"},
+		{uint8(200), "200"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{float32(0.5), "0.5"},
+		{map[string]interface{}{"b": int64(2), "a": int64(1)}, "{a: 1, b: 2}"},
+		{NewString("already an object"), "already an object"},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.value)
+		if err != nil {
+			t.Fatalf("FromGo(%v) returned error: %s", tt.value, err)
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("FromGo(%v): expected=%s, got=%s", tt.value, tt.expected, obj.Inspect())
+		}
+	}
+
+	if obj, _ := FromGo(nil); obj != NULL {
+		t.Errorf("FromGo(nil) is not NULL, got=%#v", obj)
+	}
+	if obj, _ := FromGo(true); obj != TRUE {
+		t.Errorf("FromGo(true) is not TRUE, got=%#v", obj)
+	}
+	if obj, _ := FromGo((*big.Int)(nil)); obj != NULL {
+		t.Errorf("FromGo((*big.Int)(nil)) is not NULL, got=%#v", obj)
+	}
+
+	// a big.Int that fits is an Integer, so it equals and hashes like one
+	small, _ := FromGo(big.NewInt(5))
+	integer, ok := small.(*Integer)
+	if !ok || integer.Value != 5 {
+		t.Fatalf("FromGo(big.NewInt(5)): expected Integer 5, got=%#v", small)
+	}
+	hashKey, _ := HashKeyOf(small)
+	if hashKey != NewInteger(5).HashKey() {
+		t.Errorf("FromGo(big.NewInt(5)) does not hash like 5")
+	}
+
+	for _, value := range []interface{}{
+		struct{}{},
+		[]string{"typed slices are not converted"},
+		[]interface{}{1, make(chan int)},
+	} {
+		if _, err := FromGo(value); !errors.Is(err, ErrNotConvertible) {
+			t.Errorf("FromGo(%#v): expected ErrNotConvertible, got=%v", value, err)
+		}
+	}
+}
+
+func TestToGoNotConvertible(t *testing.T) {
+	intKey := NewInteger(1)
+	intKeyed := &Hash{}
+	intKeyed.Set(intKey.HashKey(), HashPair{Key: intKey, Value: NewString("one")})
+
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{&Function{}, "not convertible: FUNCTION"},
+		{&Builtin{}, "not convertible: BUILTIN"},
+		{&Error{Message: "boom"}, "not convertible: ERROR"},
+		{NewArray([]Object{NewInteger(1), &Function{}}), "not convertible: FUNCTION"},
+		{intKeyed, "not convertible: hash key of type INTEGER"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToGo(tt.obj)
+		if !errors.Is(err, ErrNotConvertible) {
+			t.Errorf("ToGo(%T): expected ErrNotConvertible, got=%v", tt.obj, err)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("err.Error(): expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
@@ -3,6 +3,7 @@ package object
 type Environment struct {
 	store map[string]Object
 	outer *Environment
+	state interface{}
 }
 
 func NewEnvironment() *Environment {
@@ -23,8 +24,86 @@ func (e *Environment) Set(name string, val Object) Object {
 	return val
 }
 
+// Assign rebinds name in the nearest scope that already defines it
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return val, true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+	return nil, false
+}
+
+// Delete removes name from e itself, leaving outer environments untouched.
+// It reports whether e had a binding for name.
+func (e *Environment) Delete(name string) bool {
+	_, ok := e.store[name]
+	delete(e.store, name)
+	return ok
+}
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
 	return env
 }
+
+// Root returns the outermost environment enclosing e, or e itself
+func (e *Environment) Root() *Environment {
+	for e.outer != nil {
+		e = e.outer
+	}
+	return e
+}
+
+// SetState attaches interpreter state (such as evaluation limits) to e. It
+// is shared with every environment enclosed by e, including those created
+// before it was attached.
+func (e *Environment) SetState(state interface{}) {
+	e.state = state
+}
+
+// State returns the state attached to e or, failing that, to the nearest
+// outer environment that has one
+func (e *Environment) State() interface{} {
+	for env := e; env != nil; env = env.outer {
+		if env.state != nil {
+			return env.state
+		}
+	}
+	return nil
+}
+
+// Snapshot is a saved copy of the bindings in a single environment
+type Snapshot struct {
+	env   *Environment
+	store map[string]Object
+}
+
+// Snapshot records the bindings of e (not of its outer environments) so
+// they can later be rolled back with Restore. Objects themselves are not
+// copied, so in-place changes to a bound value are not undone.
+func (e *Environment) Snapshot() *Snapshot {
+	return &Snapshot{env: e, store: copyStore(e.store)}
+}
+
+// Restore rolls the bindings of e back to the given snapshot, dropping any
+// names created since. It reports false if the snapshot belongs to a
+// different environment.
+func (e *Environment) Restore(s *Snapshot) bool {
+	if s == nil || s.env != e {
+		return false
+	}
+	e.store = copyStore(s.store)
+	return true
+}
+
+func copyStore(store map[string]Object) map[string]Object {
+	copied := make(map[string]Object, len(store))
+	for name, val := range store {
+		copied[name] = val
+	}
+	return copied
+}
@@ -0,0 +1,120 @@
+package object
+
+import "testing"
+
+func TestEnvironmentSnapshotRestore(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("a", &Integer{Value: 1})
+	env.Set("b", &Integer{Value: 2})
+
+	snapshot := env.Snapshot()
+
+	env.Set("c", &Integer{Value: 3})
+	env.Set("a", &Integer{Value: 10})
+	env.Assign("b", &Integer{Value: 20})
+
+	if !env.Restore(snapshot) {
+		t.Fatalf("env.Restore(snapshot) returned false")
+	}
+
+	expected := map[string]int64{"a": 1, "b": 2}
+	for name, value := range expected {
+		obj, ok := env.Get(name)
+		if !ok {
+			t.Fatalf("binding %q missing after restore", name)
+		}
+		if obj.(*Integer).Value != value {
+			t.Errorf("binding %q: expected=%d, got=%d",
+				name, value, obj.(*Integer).Value)
+		}
+	}
+	if _, ok := env.Get("c"); ok {
+		t.Errorf("binding %q created after the snapshot survived restore", "c")
+	}
+
+	// a snapshot can be restored more than once
+	env.Set("d", &Integer{Value: 4})
+	env.Restore(snapshot)
+	if _, ok := env.Get("d"); ok {
+		t.Errorf("binding %q survived a second restore", "d")
+	}
+}
+
+func TestEnvironmentRestoreForeignSnapshot(t *testing.T) {
+	env := NewEnvironment()
+	other := NewEnvironment()
+	other.Set("x", &Integer{Value: 1})
+
+	if env.Restore(other.Snapshot()) {
+		t.Errorf("env.Restore accepted a snapshot of another environment")
+	}
+	if _, ok := env.Get("x"); ok {
+		t.Errorf("foreign snapshot leaked bindings into env")
+	}
+	if env.Restore(nil) {
+		t.Errorf("env.Restore accepted a nil snapshot")
+	}
+}
+
+func TestEnvironmentDelete(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("a", &Integer{Value: 1})
+	env := NewEnclosedEnvironment(outer)
+	env.Set("a", &Integer{Value: 2})
+	env.Set("b", &Integer{Value: 3})
+
+	if !env.Delete("b") {
+		t.Errorf("env.Delete(%q) returned false", "b")
+	}
+	if _, ok := env.Get("b"); ok {
+		t.Errorf("binding %q survived delete", "b")
+	}
+	if env.Delete("b") {
+		t.Errorf("deleting %q twice returned true", "b")
+	}
+
+	// only the local binding goes, the outer one shows through again
+	if !env.Delete("a") {
+		t.Errorf("env.Delete(%q) returned false", "a")
+	}
+	obj, ok := env.Get("a")
+	if !ok || obj.(*Integer).Value != 1 {
+		t.Errorf("outer binding of %q not visible after delete, got=%v", "a", obj)
+	}
+	if env.Delete("a") {
+		t.Errorf("env.Delete(%q) removed an outer binding", "a")
+	}
+}
+
+func TestEnvironmentState(t *testing.T) {
+	outer := NewEnvironment()
+	inner := NewEnclosedEnvironment(outer)
+	if inner.State() != nil {
+		t.Fatalf("inner.State(): expected=nil, got=%v", inner.State())
+	}
+
+	// state attached after inner was created still reaches it
+	outer.SetState("outer")
+	if inner.State() != "outer" {
+		t.Errorf("inner.State(): expected=%q, got=%v", "outer", inner.State())
+	}
+
+	inner.SetState("inner")
+	if inner.State() != "inner" {
+		t.Errorf("inner.State(): expected=%q, got=%v", "inner", inner.State())
+	}
+	if outer.State() != "outer" {
+		t.Errorf("outer.State(): expected=%q, got=%v", "outer", outer.State())
+	}
+}
+
+func TestEnvironmentRoot(t *testing.T) {
+	root := NewEnvironment()
+	inner := NewEnclosedEnvironment(NewEnclosedEnvironment(root))
+	if inner.Root() != root {
+		t.Errorf("inner.Root() is not the outermost environment")
+	}
+	if root.Root() != root {
+		t.Errorf("root.Root() is not root itself")
+	}
+}
@@ -2,8 +2,12 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/anukuljoshi/monkey/ast"
@@ -13,10 +17,15 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	BIGINT_OBJ       = "BIGINT"
+	FLOAT_OBJ        = "FLOAT"
 	STRING_OBJ       = "STRING"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
+	UNDEFINED_OBJ    = "UNDEFINED"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
@@ -34,6 +43,10 @@ type Integer struct {
 	Value int64
 }
 
+func NewInteger(value int64) *Integer {
+	return &Integer{Value: value}
+}
+
 func (i *Integer) Type() ObjectType {
 	return INTEGER_OBJ
 }
@@ -41,11 +54,62 @@ func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+// arbitrary-precision integer, used once a value no longer fits in int64
+type BigInt struct {
+	Value *big.Int
+}
+
+func (bi *BigInt) Type() ObjectType {
+	return BIGINT_OBJ
+}
+func (bi *BigInt) Inspect() string {
+	return bi.Value.String()
+}
+
+// floating point number
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// Inspect always includes a decimal point (or exponent) for finite values
+// so floats can be told apart from integers
+func (f *Float) Inspect() string {
+	str := strconv.FormatFloat(f.Value, 'f', -1, 64)
+	if math.IsInf(f.Value, 0) || math.IsNaN(f.Value) || strings.ContainsAny(str, ".e") {
+		return str
+	}
+	return str + ".0"
+}
+
 // booleans
 type Boolean struct {
 	Value bool
 }
 
+// TRUE and FALSE are the only Boolean values the interpreter creates, so
+// booleans can be compared by pointer
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
+// NativeBool returns the shared Boolean for value
+func NativeBool(value bool) *Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
+
+// NewBoolean is NativeBool, named to match the other constructors
+func NewBoolean(value bool) *Boolean {
+	return NativeBool(value)
+}
+
 func (b *Boolean) Type() ObjectType {
 	return BOOLEAN_OBJ
 }
@@ -56,6 +120,10 @@ func (b *Boolean) Inspect() string {
 // null
 type Null struct{}
 
+// NULL is the Null value the interpreter uses, like booleans it is compared
+// by pointer
+var NULL = &Null{}
+
 func (b *Null) Type() ObjectType {
 	return NULL_OBJ
 }
@@ -63,6 +131,17 @@ func (b *Null) Inspect() string {
 	return "null"
 }
 
+// undefined, the result of looking up an index or key that is not there,
+// as opposed to a null that was actually stored
+type Undefined struct{}
+
+func (u *Undefined) Type() ObjectType {
+	return UNDEFINED_OBJ
+}
+func (u *Undefined) Inspect() string {
+	return "undefined"
+}
+
 // return
 type ReturnValue struct {
 	Value Object
@@ -75,15 +154,40 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// loop control, these only travel from a break/continue to the enclosing loop
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
 // error
 type Error struct {
 	Message string
+	// Kind is set by programs raising their own errors, so handlers can
+	// tell them apart; errors from the interpreter leave it empty
+	Kind string
 }
 
 func (e *Error) Type() ObjectType {
 	return ERROR_OBJ
 }
 func (e *Error) Inspect() string {
+	if e.Kind != "" {
+		return "ERROR: " + e.Kind + ": " + e.Message
+	}
 	return "ERROR: " + e.Message
 }
 
@@ -120,6 +224,10 @@ type String struct {
 	Value string
 }
 
+func NewString(value string) *String {
+	return &String{Value: value}
+}
+
 func (s *String) Type() ObjectType {
 	return STRING_OBJ
 }
@@ -130,8 +238,13 @@ func (s *String) Inspect() string {
 // builtin functions
 type BuiltinFunction func(args ...Object) Object
 
+// BuiltinEnvFunction is a builtin that also receives the environment of the
+// call site, e.g. to look up other bindings
+type BuiltinEnvFunction func(env *Environment, args ...Object) Object
+
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn    BuiltinFunction
+	EnvFn BuiltinEnvFunction // called instead of Fn when set
 }
 
 func (b *Builtin) Type() ObjectType {
@@ -144,23 +257,55 @@ func (b *Builtin) Inspect() string {
 // array
 type Array struct {
 	Elements []Object
+	// Frozen arrays refuse index assignment
+	Frozen bool
+}
+
+// NewArray wraps elements in an Array without copying them
+func NewArray(elements []Object) *Array {
+	return &Array{Elements: elements}
 }
 
 func (a *Array) Type() ObjectType {
 	return ARRAY_OBJ
 }
 func (a *Array) Inspect() string {
-	var out bytes.Buffer
-	elements := []string{}
-	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
-	}
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
+	var out strings.Builder
+	inspectTo(&out, a)
 	return out.String()
 }
 
+// inspectTo writes the Inspect output of obj to out. Arrays and hashes
+// write their elements straight into out, rather than building a string
+// per element and joining them, so nested and large containers are
+// printed in time linear in the size of the output.
+func inspectTo(out *strings.Builder, obj Object) {
+	switch obj := obj.(type) {
+	case *Array:
+		out.WriteString("[")
+		for i, e := range obj.Elements {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			inspectTo(out, e)
+		}
+		out.WriteString("]")
+	case *Hash:
+		out.WriteString("{")
+		for i, pair := range obj.OrderedPairs() {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			inspectTo(out, pair.Key)
+			out.WriteString(": ")
+			inspectTo(out, pair.Value)
+		}
+		out.WriteString("}")
+	default:
+		out.WriteString(obj.Inspect())
+	}
+}
+
 // hash keys
 type Hashable interface {
 	HashKey() HashKey
@@ -171,6 +316,40 @@ type HashKey struct {
 	Value uint64
 }
 
+// HashKeyOf returns the hash key of obj. Besides Hashable objects, arrays
+// are usable as keys when every element is, they are not Hashable
+// themselves since that depends on their contents.
+func HashKeyOf(obj Object) (HashKey, bool) {
+	switch obj := obj.(type) {
+	case Hashable:
+		return obj.HashKey(), true
+	case *Array:
+		return obj.hashKey()
+	}
+	return HashKey{}, false
+}
+
+// the key of an array combines the keys of its elements in order
+func (a *Array) hashKey() (HashKey, bool) {
+	h := fnv.New64a()
+	value := make([]byte, 8)
+
+	for _, el := range a.Elements {
+		key, ok := HashKeyOf(el)
+		if !ok {
+			return HashKey{}, false
+		}
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(value, key.Value)
+		h.Write(value)
+	}
+
+	return HashKey{
+		Type:  a.Type(),
+		Value: h.Sum64(),
+	}, true
+}
+
 func (b *Boolean) HashKey() HashKey {
 	var value uint64
 
@@ -192,6 +371,20 @@ func (i *Integer) HashKey() HashKey {
 	}
 }
 
+func (bi *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+
+	h.Write(bi.Value.Bytes())
+	if bi.Value.Sign() < 0 {
+		h.Write([]byte{'-'})
+	}
+
+	return HashKey{
+		Type:  bi.Type(),
+		Value: h.Sum64(),
+	}
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 
@@ -211,22 +404,70 @@ type HashPair struct {
 
 type Hash struct {
 	Pairs map[HashKey]HashPair
+	// Keys lists the keys of Pairs in insertion order, it is kept up to
+	// date by Set
+	Keys []HashKey
+	// Frozen hashes refuse index assignment
+	Frozen bool
+}
+
+// Set stores pair under hashKey. A new key is appended to the insertion
+// order, while replacing the value of an existing key keeps its position.
+// An array key is stored as a frozen copy, so changing the array later
+// cannot change the key out from under the hash.
+func (h *Hash) Set(hashKey HashKey, pair HashPair) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+	if existing, ok := h.Pairs[hashKey]; ok {
+		pair.Key = existing.Key
+	} else {
+		h.Keys = append(h.Keys, hashKey)
+		pair.Key = frozenKey(pair.Key)
+	}
+	h.Pairs[hashKey] = pair
+}
+
+// frozenKey returns a frozen deep copy of an array key, other keys are
+// immutable and returned as they are
+func frozenKey(key Object) Object {
+	arr, ok := key.(*Array)
+	if !ok {
+		return key
+	}
+	elements := make([]Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		elements[i] = frozenKey(el)
+	}
+	return &Array{Elements: elements, Frozen: true}
+}
+
+// OrderedPairs returns the pairs of h in insertion order. Pairs written to
+// the map directly instead of through Set come last, in no fixed order.
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	listed := make(map[HashKey]bool, len(h.Keys))
+	for _, key := range h.Keys {
+		if pair, ok := h.Pairs[key]; ok && !listed[key] {
+			pairs = append(pairs, pair)
+			listed[key] = true
+		}
+	}
+	if len(pairs) < len(h.Pairs) {
+		for key, pair := range h.Pairs {
+			if !listed[key] {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType {
 	return HASH_OBJ
 }
 func (h *Hash) Inspect() string {
-	var out bytes.Buffer
-	elements := []string{}
-	for _, e := range h.Pairs {
-		elements = append(
-			elements,
-			fmt.Sprintf("%s: %s", e.Key.Inspect(), e.Value.Inspect()),
-		)
-	}
-	out.WriteString("{")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("}")
+	var out strings.Builder
+	inspectTo(&out, h)
 	return out.String()
 }
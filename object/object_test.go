@@ -1,6 +1,14 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anukuljoshi/monkey/ast"
+	"github.com/anukuljoshi/monkey/token"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -18,3 +26,312 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestStringHashKeyAsSetKey(t *testing.T) {
+	set := map[HashKey]bool{}
+	for _, s := range []string{"apple", "banana", "apple"} {
+		set[(&String{Value: s}).HashKey()] = true
+	}
+	if len(set) != 2 {
+		t.Errorf("set should hold 2 distinct strings, got=%d", len(set))
+	}
+
+	key := &String{Value: "banana"}
+	if !set[key.HashKey()] {
+		t.Errorf("a fresh String with equal content is not found in the set")
+	}
+	if key.HashKey() != key.HashKey() {
+		t.Errorf("HashKey() is not stable across calls")
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	array := func(elements ...Object) *Array {
+		return &Array{Elements: elements}
+	}
+	one := &Integer{Value: 1}
+	two := &Integer{Value: 2}
+
+	key := func(obj Object) HashKey {
+		k, ok := HashKeyOf(obj)
+		if !ok {
+			t.Fatalf("%s should be usable as a hash key", obj.Inspect())
+		}
+		return k
+	}
+
+	if key(array(one, two)) != key(array(&Integer{Value: 1}, &Integer{Value: 2})) {
+		t.Errorf("arrays with equal elements have different hash keys")
+	}
+	if key(array(one, two)) == key(array(two, one)) {
+		t.Errorf("element order does not change the hash key")
+	}
+	if key(array(one)) == key(array(&String{Value: "1"})) {
+		t.Errorf("elements of different types have the same hash key")
+	}
+	if key(array(array(one), two)) != key(array(array(one), two)) {
+		t.Errorf("nested arrays with equal elements have different hash keys")
+	}
+	if key(array()) == key(array(array())) {
+		t.Errorf("empty and nested empty arrays have the same hash key")
+	}
+
+	for _, obj := range []Object{
+		array(one, &Hash{}),
+		array(array(&Float{Value: 1.5})),
+		&Hash{},
+	} {
+		if _, ok := HashKeyOf(obj); ok {
+			t.Errorf("%s should not be usable as a hash key", obj.Inspect())
+		}
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	hash := &Hash{}
+	for _, name := range []string{"zeta", "alpha", "mid"} {
+		key := &String{Value: name}
+		hash.Set(key.HashKey(), HashPair{Key: key, Value: &Integer{Value: 1}})
+	}
+	// replacing a value keeps the original position
+	alpha := &String{Value: "alpha"}
+	hash.Set(alpha.HashKey(), HashPair{Key: alpha, Value: &Integer{Value: 2}})
+
+	expected := "{zeta: 1, alpha: 2, mid: 1}"
+	for i := 0; i < 10; i++ {
+		if hash.Inspect() != expected {
+			t.Fatalf("hash.Inspect(): expected=%q, got=%q", expected, hash.Inspect())
+		}
+	}
+	if len(hash.Keys) != 3 {
+		t.Errorf("len(hash.Keys): expected=3, got=%d", len(hash.Keys))
+	}
+
+	// pairs added to the map directly still show up, after the ordered ones
+	extra := &String{Value: "extra"}
+	hash.Pairs[extra.HashKey()] = HashPair{Key: extra, Value: &Integer{Value: 3}}
+	expected = "{zeta: 1, alpha: 2, mid: 1, extra: 3}"
+	if hash.Inspect() != expected {
+		t.Errorf("hash.Inspect(): expected=%q, got=%q", expected, hash.Inspect())
+	}
+}
+
+func TestNativeBool(t *testing.T) {
+	if NativeBool(true) != TRUE || NativeBool(false) != FALSE {
+		t.Fatalf("NativeBool does not return the shared singletons")
+	}
+	if NativeBool(1 < 2) != NativeBool(2 > 1) {
+		t.Errorf("independently produced true values are different objects")
+	}
+	if NativeBool(true).Value != true || NativeBool(false).Value != false {
+		t.Errorf("singletons hold the wrong values")
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	if NewBoolean(true) != TRUE || NewBoolean(false) != FALSE {
+		t.Errorf("NewBoolean does not return the shared singletons")
+	}
+	if n := NewInteger(-7); n.Value != -7 || n.Inspect() != "-7" {
+		t.Errorf("NewInteger(-7) wrong, got=%+v", n)
+	}
+	if s := NewString("hi"); s.Value != "hi" || s.Type() != STRING_OBJ {
+		t.Errorf("NewString(%q) wrong, got=%+v", "hi", s)
+	}
+
+	elements := []Object{NewInteger(1), NewString("a"), NewBoolean(true)}
+	arr := NewArray(elements)
+	if arr.Inspect() != "[1, a, true]" {
+		t.Errorf("arr.Inspect(): expected=%q, got=%q", "[1, a, true]", arr.Inspect())
+	}
+	if NewArray(nil).Inspect() != "[]" {
+		t.Errorf("NewArray(nil).Inspect(): expected=%q, got=%q", "[]", NewArray(nil).Inspect())
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1.5, "1.5"},
+		{2, "2.0"},
+		{-0.25, "-0.25"},
+		{1500, "1500.0"},
+		{math.Inf(1), "+Inf"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("Float{%v}.Inspect(): expected=%q, got=%q",
+				tt.value, tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestLargeArrayInspect(t *testing.T) {
+	const n = 100000
+	elements := make([]Object, n)
+	for i := range elements {
+		elements[i] = &Integer{Value: int64(i % 10)}
+	}
+	nested := &Array{Elements: []Object{
+		&Array{Elements: elements},
+		&Hash{},
+	}}
+
+	start := time.Now()
+	inspected := nested.Inspect()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Inspect of %d elements took %s", n, elapsed)
+	}
+
+	// "[[" + n digits joined by ", " + "], {}]"
+	expectedLen := 2 + n + 2*(n-1) + 6
+	if len(inspected) != expectedLen {
+		t.Fatalf("len(inspected): expected=%d, got=%d", expectedLen, len(inspected))
+	}
+	if !strings.HasPrefix(inspected, "[[0, 1, 2, ") || !strings.HasSuffix(inspected, "8, 9], {}]") {
+		t.Errorf("unexpected output: %s...%s", inspected[:20], inspected[len(inspected)-20:])
+	}
+}
+
+func BenchmarkArrayInspect(b *testing.B) {
+	rows := make([]Object, 1000)
+	for i := range rows {
+		key := &String{Value: "n"}
+		row := &Hash{}
+		row.Set(key.HashKey(), HashPair{Key: key, Value: &Array{Elements: []Object{
+			&Integer{Value: int64(i)}, &String{Value: "x"}, TRUE,
+		}}})
+		rows[i] = row
+	}
+	arr := &Array{Elements: rows}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		arr.Inspect()
+	}
+}
+
+func TestFunctionInspect(t *testing.T) {
+	x := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	y := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "y"}, Value: "y"}
+	sum := &ast.InfixExpression{
+		Token:    token.Token{Type: token.PLUS, Literal: "+"},
+		Left:     x,
+		Operator: "+",
+		Right:    y,
+	}
+
+	tests := []struct {
+		fn       *Function
+		expected string
+	}{
+		{
+			&Function{
+				Parameters: []*ast.Identifier{x, y},
+				Body: &ast.BlockStatement{Statements: []ast.Statement{
+					&ast.ExpressionStatement{Expression: sum},
+				}},
+			},
+			"fn(x, y) {\n(x + y)\n}",
+		},
+		{
+			&Function{Parameters: []*ast.Identifier{}, Body: &ast.BlockStatement{}},
+			"fn() {\n\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.fn.Inspect() != tt.expected {
+			t.Errorf("fn.Inspect(): expected=%q, got=%q", tt.expected, tt.fn.Inspect())
+		}
+	}
+}
+
+func TestErrorInspect(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		expected string
+	}{
+		{&Error{Message: "identifier not found: foobar"}, "ERROR: identifier not found: foobar"},
+		{&Error{Message: "bad input", Kind: "ValueError"}, "ERROR: ValueError: bad input"},
+		{&Error{}, "ERROR: "},
+	}
+
+	for _, tt := range tests {
+		if tt.err.Inspect() != tt.expected {
+			t.Errorf("err.Inspect(): expected=%q, got=%q", tt.expected, tt.err.Inspect())
+		}
+	}
+
+	// the prefix is only part of the printed form
+	err := &Error{Message: "identifier not found: foobar"}
+	if err.Message != "identifier not found: foobar" {
+		t.Errorf("err.Message changed: %q", err.Message)
+	}
+}
+
+func TestArrayInspect(t *testing.T) {
+	one := &Integer{Value: 1}
+	two := &Integer{Value: 2}
+	three := &Integer{Value: 3}
+
+	tests := []struct {
+		arr      *Array
+		expected string
+	}{
+		{&Array{}, "[]"},
+		{&Array{Elements: []Object{one}}, "[1]"},
+		{&Array{Elements: []Object{one, two, three}}, "[1, 2, 3]"},
+		{
+			&Array{Elements: []Object{
+				&Array{},
+				&Array{Elements: []Object{one, &Array{Elements: []Object{two}}}},
+				three,
+			}},
+			"[[], [1, [2]], 3]",
+		},
+		{
+			&Array{Elements: []Object{&String{Value: "a"}, TRUE, &Float{Value: 2}, &Null{}}},
+			"[a, true, 2.0, null]",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.arr.Inspect() != tt.expected {
+			t.Errorf("arr.Inspect(): expected=%q, got=%q", tt.expected, tt.arr.Inspect())
+		}
+	}
+}
+
+func TestHashInspect(t *testing.T) {
+	hash := &Hash{}
+	if hash.Inspect() != "{}" {
+		t.Errorf("empty hash.Inspect(): expected=%q, got=%q", "{}", hash.Inspect())
+	}
+
+	keys := []Object{
+		&String{Value: "b"},
+		&Integer{Value: 2},
+		TRUE,
+		&String{Value: "a"},
+		&Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "x"}}},
+	}
+	for i, key := range keys {
+		hashKey, ok := HashKeyOf(key)
+		if !ok {
+			t.Fatalf("%s is not usable as a hash key", key.Inspect())
+		}
+		hash.Set(hashKey, HashPair{Key: key, Value: &Integer{Value: int64(i)}})
+	}
+	nested := &String{Value: "nested"}
+	hash.Set(nested.HashKey(), HashPair{Key: nested, Value: &Hash{}})
+
+	expected := "{b: 0, 2: 1, true: 2, a: 3, [1, x]: 4, nested: {}}"
+	if hash.Inspect() != expected {
+		t.Errorf("hash.Inspect(): expected=%q, got=%q", expected, hash.Inspect())
+	}
+}
@@ -10,24 +10,36 @@ import (
 
 const (
 	LOWEST        = 1
-	EQUALS        = 2 // ==
-	LESSERGREATER = 3 // < or >
-	SUM           = 4 // +
-	PRODUCT       = 5 // *
-	PREFIX        = 6 // -x or !x
-	CALL          = 7 // myFunction(x)
-	INDEX         = 8 // myFunction(x)
+	ASSIGNMENT    = 2  // x = y
+	LOGICAL_OR    = 3  // || or
+	LOGICAL_AND   = 4  // && and
+	EQUALS        = 5  // ==
+	LESSERGREATER = 6  // < or >
+	SUM           = 7  // +
+	PRODUCT       = 8  // *
+	PREFIX        = 9  // -x or !x
+	POSTFIX       = 10 // x++ or x--
+	CALL          = 11 // myFunction(x)
+	INDEX         = 12 // myFunction(x)
 )
 
 var precendences = map[token.TokenType]int{
+	token.ASSIGN:   ASSIGNMENT,
+	token.OR:       LOGICAL_OR,
+	token.AND:      LOGICAL_AND,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSERGREATER,
 	token.GT:       LESSERGREATER,
+	token.LT_EQ:    LESSERGREATER,
+	token.GT_EQ:    LESSERGREATER,
+	token.IN:       LESSERGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.FSLASH:   PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.INC:      POSTFIX,
+	token.DEC:      POSTFIX,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
 }
@@ -63,14 +75,23 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifer)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.PLUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.WHILE, p.parseWhileExpression)
+	p.registerPrefix(token.DO, p.parseDoWhileExpression)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+	p.registerPrefix(token.FOR, p.parseForExpression)
+	p.registerPrefix(token.SWITCH, p.parseSwitchExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.RAW_STRING, p.parseRawStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
@@ -80,11 +101,19 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.FSLASH, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
-	p.registerInfix(token.LT, p.parseInfixExpression)
-	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT, p.parseComparisonExpression)
+	p.registerInfix(token.GT, p.parseComparisonExpression)
+	p.registerInfix(token.LT_EQ, p.parseComparisonExpression)
+	p.registerInfix(token.GT_EQ, p.parseComparisonExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.INC, p.parsePostfixExpression)
+	p.registerInfix(token.DEC, p.parsePostfixExpression)
 	return p
 }
 
@@ -94,8 +123,7 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt := p.parseStatementOrSkip(); stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
@@ -103,12 +131,59 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatementOrSkip parses a statement, or when that fails skips the
+// rest of it and returns nil. Parsing then resumes at the next statement,
+// so each broken statement is reported once instead of its leftover tokens
+// producing more errors.
+func (p *Parser) parseStatementOrSkip() ast.Statement {
+	errors := len(p.errors)
+	stmt := p.parseStatement()
+	if len(p.errors) == errors {
+		return stmt
+	}
+	p.skipStatement()
+	return nil
+}
+
+// skipStatement advances to the last token of the current statement: the
+// semicolon ending it, the token before the closing brace of the enclosing
+// block, or a closing brace the statement opened before it failed. Braces
+// opened while skipping are skipped along with their contents.
+func (p *Parser) skipStatement() {
+	depth := 0
+	for !p.curTokenIs(token.EOF) && !p.peekTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+			if depth < 0 {
+				return
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+		if depth == 0 && p.peekTokenIs(token.RBRACE) {
+			return
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -142,11 +217,48 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
-	p.nextToken()
+	// a bare return leaves ReturnValue nil and returns null
+	if !p.peekTokenIs(token.SEMICOLON) && !p.peekTokenIs(token.RBRACE) &&
+		!p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		stmt.ReturnValue = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
 
-	stmt.ReturnValue = p.parseExpression(LOWEST)
+// parser for loop control statements
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parser for import statements
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
@@ -188,6 +300,9 @@ func (p *Parser) parseExpression(precendence int) ast.Expression {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+	if p.peekTokenIs(token.ILLEGAL) {
+		p.illegalTokenError(p.peekToken)
+	}
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -241,6 +356,16 @@ func (p *Parser) peekError(t token.TokenType) {
 }
 
 func (p *Parser) noPrefixParseFnError(tokenType token.TokenType) {
+	// illegal tokens are reported as soon as they are read
+	if tokenType == token.ILLEGAL {
+		return
+	}
 	msg := fmt.Sprintf("no prefix parse function found for %s", tokenType)
 	p.errors = append(p.errors, msg)
 }
+
+func (p *Parser) illegalTokenError(tok token.Token) {
+	msg := fmt.Sprintf("illegal token at line %d, column %d: %s",
+		tok.Line, tok.Column, tok.Literal)
+	p.errors = append(p.errors, msg)
+}
@@ -71,6 +71,8 @@ func TestReturnStatements(t *testing.T) {
 		{"return 5;", 5},
 		{"return true", true},
 		{"return foobar", "foobar"},
+		{"return;", nil},
+		{"return", nil},
 	}
 
 	for _, tt := range tests {
@@ -96,7 +98,14 @@ func TestReturnStatements(t *testing.T) {
 				"return", returnStmt.TokenLiteral())
 		}
 
-		if testLiteralExpression(
+		if tt.expectedReturnValue == nil {
+			if returnStmt.ReturnValue != nil {
+				t.Errorf("returnStmt.ReturnValue: expected nil, got=%s",
+					returnStmt.ReturnValue.String())
+			}
+			continue
+		}
+		if !testLiteralExpression(
 			t,
 			returnStmt.ReturnValue,
 			tt.expectedReturnValue,
@@ -106,6 +115,29 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestBareReturnInBlocks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn() { return; }", "fn() { return; }"},
+		{"fn() { return }", "fn() { return; }"},
+		{"fn(x) { if (x) { return } x }", "fn(x) { if (x) { return; }; x }"},
+		{"fn() { return 1 }; f()", "fn() { return 1; }; f()"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := "foobar;"
 
@@ -173,6 +205,7 @@ func TestPrefixExpressions(t *testing.T) {
 	}{
 		{"!5;", "!", 5},
 		{"-15;", "-", 15},
+		{"+15;", "+", 15},
 		{"!true;", "!", true},
 		{"!false;", "!", false},
 	}
@@ -262,6 +295,50 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a or b and c",
+			"(a or (b and c))",
+		},
+		{
+			"a and b || c && d or e",
+			"(((a and b) || (c && d)) or e)",
+		},
+		{
+			"a == 1 && b < 2 || !c",
+			"(((a == 1) && (b < 2)) || (!c))",
+		},
+		{
+			"x = a || b",
+			"(x = (a || b))",
+		},
+		{
+			"not a == b",
+			"((not a) == b)",
+		},
+		{
+			"not not true",
+			"(not (not true))",
+		},
+		{
+			"+a * b",
+			"((+a) * b)",
+		},
+		{
+			"a + +b",
+			"(a + (+b))",
+		},
+		{
+			"a + 1 <= b == c >= 2",
+			"(((a + 1) <= b) == (c >= 2))",
+		},
+		{
+			"a + 1 in b == true",
+			"(((a + 1) in b) == true)",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -293,7 +370,7 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		// tests with integer literals
 		{
 			"3 + 4; -5 * 5",
-			"(3 + 4)((-5) * 5)",
+			"(3 + 4); ((-5) * 5)",
 		},
 		{
 			"5 > 4 == 3 < 4",
@@ -371,6 +448,27 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		// postfix and assignment
+		{
+			"-i++",
+			"(-(i++))",
+		},
+		{
+			"a + b--",
+			"(a + (b--))",
+		},
+		{
+			"a = b = 1 + 2",
+			"(a = (b = (1 + 2)))",
+		},
+		{
+			"1 < x + 1 <= 10 == ok",
+			"((1 < (x + 1) <= 10) == ok)",
+		},
+		{
+			"a < b < c && c > d",
+			"((a < b < c) && (c > d))",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -493,6 +591,56 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	t.FailNow()
 }
 
+func TestGroupedExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 * (5 + 10)", "(2 * (5 + 10))"},
+		{"3 * (3 * 3) + 10", "((3 * (3 * 3)) + 10)"},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", "((((5 + (10 * 2)) + (15 / 3)) * 2) + (-10))"},
+		{"((1))", "1"},
+		{"((a + b) * (c - d)) / e", "(((a + b) * (c - d)) / e)"},
+		{"-(-(a))", "(-(-a))"},
+		{"f((a + b) * c)", "f(((a + b) * c))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"(1 + 2", "expected next token to be ), got EOF instead"},
+		{"((1 + 2) * 3", "expected next token to be ), got EOF instead"},
+		{"(1 + 2;", "expected next token to be ), got ; instead"},
+		{"()", "no prefix parse function found for )"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := `
 	true;
@@ -533,6 +681,32 @@ func TestBooleanExpression(t *testing.T) {
 		if exp.TokenLiteral() != tt.expectedTokenValue {
 			t.Fatalf("TestBooleanExpression %d: exp.TokenLiteral() expected=%s, got=%s", i, tt.expectedTokenValue, exp.TokenLiteral())
 		}
+		if exp.String() != tt.expectedTokenValue {
+			t.Fatalf("TestBooleanExpression %d: exp.String() expected=%s, got=%s", i, tt.expectedTokenValue, exp.String())
+		}
+	}
+}
+
+func TestBooleanExpressionString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let t = true;", "let t = true;"},
+		{"true == !false", "(true == (!false))"},
+		{"[true, false]", "[true, false]"},
+		{"f(false)", "f(false)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q", tt.expected, program.String())
+		}
 	}
 }
 
@@ -579,6 +753,11 @@ func TestIfExpression(t *testing.T) {
 	if exp.Alternative != nil {
 		t.Fatalf("exp.Alternative was not nil, got=%+v", exp.Alternative)
 	}
+
+	expected := "if ((x < y)) { x }"
+	if exp.String() != expected {
+		t.Errorf("exp.String(): expected=%q, got=%q", expected, exp.String())
+	}
 }
 
 // test if else statement
@@ -633,6 +812,87 @@ func TestIfElseExpression(t *testing.T) {
 	if !testIdentifier(t, alternative.Expression, "y") {
 		return
 	}
+
+	expected := "if ((x < y)) { x } else { y }"
+	if exp.String() != expected {
+		t.Errorf("exp.String(): expected=%q, got=%q", expected, exp.String())
+	}
+}
+
+func TestElseIfExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"if (a) { 1 } else if (b) { 2 }",
+			"if (a) { 1 } else if (b) { 2 }",
+		},
+		{
+			"if (a) { 1 } else if (b) { 2 } else { 3 }",
+			"if (a) { 1 } else if (b) { 2 } else { 3 }",
+		},
+		{
+			"if (a) { 1 } else if (b) { 2 } else if (c) { 3 } else { 4 }",
+			"if (a) { 1 } else if (b) { 2 } else if (c) { 3 } else { 4 }",
+		},
+		// an if nested in a plain else block keeps its braces
+		{
+			"if (a) { 1 } else { if (b) { 2 } }",
+			"if (a) { 1 } else { if (b) { 2 } }",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements): expected=%d, got=%d",
+				1, len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q",
+				tt.expected, program.String())
+		}
+	}
+
+	program := New(lexer.New("if (a) { 1 } else if (b) { 2 } else { 3 }")).ParseProgram()
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("len(exp.Alternative.Statements): expected=%d, got=%d",
+			1, len(exp.Alternative.Statements))
+	}
+	nested, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("else if alternative is not *ast.IfExpression, got=%T",
+			exp.Alternative.Statements[0])
+	}
+	testIdentifier(t, nested.Condition, "b")
+	if nested.Alternative == nil || len(nested.Alternative.Statements) != 1 {
+		t.Fatalf("nested if is missing its else block")
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (a) { 1 } else if { 2 }", "expected next token to be (, got { instead"},
+		{"if (a) { 1 } else if (b) 2", "expected next token to be {, got INT instead"},
+	}
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
 }
 
 // test for function literal
@@ -680,6 +940,47 @@ func TestParsingFunctionLiteral(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
+func TestParsingNamedFunctionLiteral(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedName string
+	}{
+		{"fn fib(n) { n }", "fib"},
+		{"fn(n) { n }", ""},
+		{"let f = fn inner(n) { n };", "inner"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		var function *ast.FunctionLiteral
+		switch stmt := program.Statements[0].(type) {
+		case *ast.ExpressionStatement:
+			function, _ = stmt.Expression.(*ast.FunctionLiteral)
+		case *ast.LetStatement:
+			function, _ = stmt.Value.(*ast.FunctionLiteral)
+		}
+		if function == nil {
+			t.Fatalf("%q did not parse to a function literal", tt.input)
+		}
+
+		if tt.expectedName == "" {
+			if function.Name != nil {
+				t.Errorf("function.Name: expected nil, got=%q", function.Name.Value)
+			}
+			continue
+		}
+		if function.Name == nil {
+			t.Fatalf("function.Name is nil, expected=%q", tt.expectedName)
+		}
+		testLiteralExpression(t, function.Name, tt.expectedName)
+		testLiteralExpression(t, function.Parameters[0], "n")
+	}
+}
+
 // test for function parameters
 func TestParsingFunctionParameters(t *testing.T) {
 	tests := []struct {
@@ -751,6 +1052,53 @@ func TestCallExpressionParsing(t *testing.T) {
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
 
+func TestCallExpressionArgumentParsing(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedIdent string
+		expectedArgs  []string
+	}{
+		{"add();", "add", []string{}},
+		{"add(1);", "add", []string{"1"}},
+		{"add(1, 2 * 3, 4 + 5);", "add", []string{"1", "(2 * 3)", "(4 + 5)"}},
+		{"add(f(x), [y]);", "add", []string{"f(x)", "[y]"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.CallExpression, got=%T",
+				stmt.Expression)
+		}
+		if !testIdentifier(t, exp.Function, tt.expectedIdent) {
+			return
+		}
+		if len(exp.Arguments) != len(tt.expectedArgs) {
+			t.Fatalf("len(exp.Arguments): expected=%d, got=%d",
+				len(tt.expectedArgs), len(exp.Arguments))
+		}
+		for i, arg := range tt.expectedArgs {
+			if exp.Arguments[i].String() != arg {
+				t.Errorf("exp.Arguments[%d]: expected=%q, got=%q",
+					i, arg, exp.Arguments[i].String())
+			}
+		}
+	}
+
+	// calls apply to any expression producing a function
+	program := New(lexer.New("fn(x, y) { x + y }(1, 2)(3)")).ParseProgram()
+	expected := "fn(x, y) { (x + y) }(1, 2)(3)"
+	if program.String() != expected {
+		t.Errorf("program.String(): expected=%q, got=%q", expected, program.String())
+	}
+}
+
 func testStringLiteral(t *testing.T, sl ast.Expression, expected string) bool {
 	result, ok := sl.(*ast.StringLiteral)
 	if !ok {
@@ -836,6 +1184,51 @@ func TestParsingIndexExpression(t *testing.T) {
 	}
 }
 
+func TestArrayAndIndexString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2*2, 3+3]", "[1, (2 * 2), (3 + 3)]"},
+		{"[]", "[]"},
+		{"[[1], []]", "[[1], []]"},
+		{"arr[1+1]", "(arr[(1 + 1)])"},
+		{"arr[0][1]", "((arr[0])[1])"},
+		{"[1, 2][0]", "([1, 2][0])"},
+		{"a * b[2] + f(c)[0]", "((a * (b[2])) + (f(c)[0]))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2", "expected next token to be ], got EOF instead"},
+		{"arr[1", "expected next token to be ], got EOF instead"},
+	}
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
 // hash literal with string keys
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	input := `{"one": 1, "two": 2, "three": 3}`
@@ -861,7 +1254,7 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
 		}
-		expectedValue := expected[literal.String()]
+		expectedValue := expected[literal.Value]
 		testIntegerLiteral(t, value, expectedValue)
 	}
 }
@@ -898,11 +1291,766 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
 			continue
 		}
-		testFunc, ok := tests[literal.String()]
+		testFunc, ok := tests[literal.Value]
 		if !ok {
-			t.Errorf("No test function for key %q found", literal.String())
+			t.Errorf("No test function for key %q found", literal.Value)
 			continue
 		}
 		testFunc(value)
 	}
 }
+
+func TestHashLiteralKeyOrder(t *testing.T) {
+	input := `{"z": 1, "a": 2, 3: 3, true: 4}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []string{`"z"`, `"a"`, "3", "true"}
+	if len(hash.Keys) != len(expected) {
+		t.Fatalf("hash.Keys has wrong length. got=%d", len(hash.Keys))
+	}
+	for i, key := range hash.Keys {
+		if key.String() != expected[i] {
+			t.Errorf("hash.Keys[%d]: expected=%s, got=%s", i, expected[i], key.String())
+		}
+	}
+	if hash.String() != `{"z": 1, "a": 2, 3: 3, true: 4}` {
+		t.Errorf("hash.String() wrong. got=%s", hash.String())
+	}
+}
+
+func TestHashLiteralString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{}", "{}"},
+		{"{1 + 1: two, [1]: x}", "{(1 + 1): two, [1]: x}"},
+		{`{f("k"): {}, a[0]: b * 2}`, `{f("k"): {}, (a[0]): (b * 2)}`},
+		// duplicate keys are kept in order, the evaluator lets the last win
+		{`{"a": 1, "a": 2}`, `{"a": 1, "a": 2}`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.HashLiteral); !ok {
+			t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a" 1}`, "expected next token to be :, got INT instead"},
+		{`{"a": 1 "b": 2}`, "expected next token to be ,, got STRING instead"},
+		{`{"a": 1`, "expected next token to be ,, got EOF instead"},
+	}
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "99999999999999999999;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.BigIntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.BigIntegerLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value.String() != "99999999999999999999" {
+		t.Errorf("literal.Value not %s. got=%s", "99999999999999999999",
+			literal.Value.String())
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "3.25;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 3.25 {
+		t.Errorf("literal.Value not %f. got=%f", 3.25, literal.Value)
+	}
+	if literal.String() != "3.25" {
+		t.Errorf("literal.String() not %q. got=%q", "3.25", literal.String())
+	}
+}
+
+func TestPostfixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+		ident    string
+	}{
+		{"i++;", "++", "i"},
+		{"count--;", "--", "count"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.PostfixExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.PostfixExpression, got=%T",
+				stmt.Expression)
+		}
+		if exp.Operator != tt.operator {
+			t.Fatalf("exp.Operator is not %s, got=%s", tt.operator, exp.Operator)
+		}
+		testIdentifier(t, exp.Left, tt.ident)
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	input := "x = 5 * 2;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.AssignExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.AssignExpression, got=%T",
+			stmt.Expression)
+	}
+	testIdentifier(t, exp.Name, "x")
+	testInfixExpression(t, exp.Value, 5, "*", 2)
+}
+
+func TestChainedComparison(t *testing.T) {
+	input := "0 <= x < y * 2 > 1"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	chain, ok := stmt.Expression.(*ast.ChainedComparison)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.ChainedComparison, got=%T", stmt.Expression)
+	}
+	if len(chain.Operands) != 4 {
+		t.Fatalf("len(chain.Operands): expected=4, got=%d", len(chain.Operands))
+	}
+	expectedOperators := []string{"<=", "<", ">"}
+	for i, op := range expectedOperators {
+		if chain.Operators[i] != op {
+			t.Errorf("chain.Operators[%d]: expected=%q, got=%q", i, op, chain.Operators[i])
+		}
+	}
+	if !testLiteralExpression(t, chain.Operands[0], 0) {
+		return
+	}
+	if !testLiteralExpression(t, chain.Operands[1], "x") {
+		return
+	}
+	if !testInfixExpression(t, chain.Operands[2], "y", "*", 2) {
+		return
+	}
+	if !testLiteralExpression(t, chain.Operands[3], 1) {
+		return
+	}
+
+	// parentheses keep a comparison out of the chain
+	l = lexer.New("(a < b) < c")
+	p = New(l)
+	program = p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt = program.Statements[0].(*ast.ExpressionStatement)
+	outer, ok := stmt.Expression.(*ast.InfixExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.InfixExpression, got=%T", stmt.Expression)
+	}
+	if !testInfixExpression(t, outer.Left, "a", "<", "b") {
+		return
+	}
+	if !testLiteralExpression(t, outer.Right, "c") {
+		return
+	}
+}
+
+func TestIndexAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[0] = 1", "((a[0]) = 1)"},
+		{`h["k"] = v + 1`, `((h["k"]) = (v + 1))`},
+		{"m[0][1] = 2", "(((m[0])[1]) = 2)"},
+		{"a[0] = b[1] = 3", "((a[0]) = ((b[1]) = 3))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.IndexAssignExpression); !ok {
+			t.Fatalf("stmt.Expression is not *ast.IndexAssignExpression, got=%T",
+				stmt.Expression)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q",
+				tt.expected, program.String())
+		}
+	}
+}
+
+func TestInvalidAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 = 1;", "cannot assign to 5"},
+		{"5++;", "cannot apply ++ to 5"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestWhileExpression(t *testing.T) {
+	input := `while (x < y) { x++ }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("len(program.Statements): expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.WhileExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.WhileExpression, got=%T",
+			stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+	if len(exp.Body.Statements) != 1 {
+		t.Fatalf("len(exp.Body.Statements): expected=%d, got=%d",
+			1, len(exp.Body.Statements))
+	}
+}
+
+func TestDoWhileExpression(t *testing.T) {
+	input := `do { x++ } while (x < y)`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("len(program.Statements): expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.DoWhileExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.DoWhileExpression, got=%T",
+			stmt.Expression)
+	}
+	if len(exp.Body.Statements) != 1 {
+		t.Fatalf("len(exp.Body.Statements): expected=%d, got=%d",
+			1, len(exp.Body.Statements))
+	}
+	testInfixExpression(t, exp.Condition, "x", "<", "y")
+}
+
+func TestForExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (let i = 0; i < 10; i++) { x }", "for (let i = 0; (i < 10); (i++)) { x }"},
+		{"for (i = 0; i < 10; i++) { x }", "for ((i = 0); (i < 10); (i++)) { x }"},
+		{"for (;;) { break; }", "for (; ; ) { break; }"},
+		{"for (; i < 10;) { continue; }", "for (; (i < 10); ) { continue; }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements): expected=%d, got=%d",
+				1, len(program.Statements))
+		}
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.ForExpression); !ok {
+			t.Fatalf("stmt.Expression is not *ast.ForExpression, got=%T",
+				stmt.Expression)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String(): expected=%q, got=%q",
+				tt.expected, program.String())
+		}
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	input := "try { risky(); 1 } catch (err) { handle(err) }"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("len(program.Statements): expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.TryExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.TryExpression, got=%T",
+			stmt.Expression)
+	}
+	if len(exp.Body.Statements) != 2 {
+		t.Errorf("len(exp.Body.Statements): expected=%d, got=%d",
+			2, len(exp.Body.Statements))
+	}
+	testLiteralExpression(t, exp.Param, "err")
+	if len(exp.Handler.Statements) != 1 {
+		t.Errorf("len(exp.Handler.Statements): expected=%d, got=%d",
+			1, len(exp.Handler.Statements))
+	}
+
+	expected := "try { risky(); 1 } catch (err) { handle(err) }"
+	if program.String() != expected {
+		t.Errorf("program.String(): expected=%q, got=%q", expected, program.String())
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { 1 }", "expected next token to be CATCH, got EOF instead"},
+		{"try { 1 } catch { 2 }", "expected next token to be (, got { instead"},
+		{"try { 1 } catch (1) { 2 }", "expected next token to be IDENT, got INT instead"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestLoopControlStatements(t *testing.T) {
+	input := `break; continue`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("len(program.Statements): expected=%d, got=%d",
+			2, len(program.Statements))
+	}
+	if _, ok := program.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("program.Statements[0] is not *ast.BreakStatement, got=%T",
+			program.Statements[0])
+	}
+	if _, ok := program.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("program.Statements[1] is not *ast.ContinueStatement, got=%T",
+			program.Statements[1])
+	}
+}
+
+func TestSwitchExpression(t *testing.T) {
+	input := `switch (x) { case 1: "one"; case 1 + 1: "two"; default: "other" }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("len(program.Statements): expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.SwitchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.SwitchExpression, got=%T",
+			stmt.Expression)
+	}
+	if !testIdentifier(t, exp.Subject, "x") {
+		return
+	}
+	if len(exp.Cases) != 2 {
+		t.Fatalf("len(exp.Cases): expected=%d, got=%d", 2, len(exp.Cases))
+	}
+	testIntegerLiteral(t, exp.Cases[0].Value, 1)
+	testInfixExpression(t, exp.Cases[1].Value, 1, "+", 1)
+	for i, arm := range exp.Cases {
+		if len(arm.Body.Statements) != 1 {
+			t.Errorf("len(exp.Cases[%d].Body.Statements): expected=%d, got=%d",
+				i, 1, len(arm.Body.Statements))
+		}
+	}
+	if exp.Default == nil || len(exp.Default.Statements) != 1 {
+		t.Fatalf("exp.Default: expected a single statement, got=%+v", exp.Default)
+	}
+}
+
+func TestSwitchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"switch (x) { 1: 2 }", "expected case or default in switch, got INT"},
+		{"switch (x) { default: 1 default: 2 }", "multiple default cases in switch"},
+		{"switch (x) { case 1 2 }", "expected next token to be :, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestInterpolatedStringExpression(t *testing.T) {
+	input := `"hello ${name}, you are ${age + 1}"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.InterpolatedString, got=%T",
+			stmt.Expression)
+	}
+	if len(str.Parts) != 4 {
+		t.Fatalf("len(str.Parts): expected=%d, got=%d", 4, len(str.Parts))
+	}
+	testStringLiteral(t, str.Parts[0], "hello ")
+	testIdentifier(t, str.Parts[1], "name")
+	testStringLiteral(t, str.Parts[2], ", you are ")
+	testInfixExpression(t, str.Parts[3], "age", "+", 1)
+}
+
+func TestRawStringIsNotInterpolated(t *testing.T) {
+	input := "`hello ${name}`"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	testStringLiteral(t, stmt.Expression, "hello ${name}")
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello ${name"`, `unterminated ${ in string "hello ${name"`},
+		{`"${ {1: 2 }"`, `unterminated ${ in string "${ {1: 2 }"`},
+		{`"${1; 2}"`, "expected a single expression in ${1; 2}"},
+		{`"${}"`, "expected a single expression in ${}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("errors[0]: expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			`let s = "never closed`,
+			[]string{"illegal token at line 1, column 9: unterminated string"},
+		},
+		{
+			"let a = 1;\nlet b = `raw",
+			[]string{"illegal token at line 2, column 9: unterminated raw string"},
+		},
+		{
+			"1 + @",
+			[]string{"illegal token at line 1, column 5: @"},
+		},
+		{
+			"let x = 2 * 1e;",
+			[]string{"illegal token at line 1, column 13: malformed exponent in 1e"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expected) {
+			t.Fatalf("len(errors): expected=%d, got=%d (%q)",
+				len(tt.expected), len(errors), errors)
+		}
+		for i, msg := range tt.expected {
+			if errors[i] != msg {
+				t.Errorf("errors[%d]: expected=%q, got=%q", i, msg, errors[i])
+			}
+		}
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+		expectedString string
+	}{
+		{
+			"let = 5; let y = 3 +; y",
+			[]string{
+				"expected next token to be IDENT, got = instead",
+				"no prefix parse function found for ;",
+			},
+			"y",
+		},
+		{
+			"let x 5; x + 1; let y = );",
+			[]string{
+				"expected next token to be =, got INT instead",
+				"no prefix parse function found for )",
+			},
+			"(x + 1)",
+		},
+		{
+			"if (x { 1 }; let a = ; a",
+			[]string{
+				"expected next token to be ), got { instead",
+				"no prefix parse function found for ;",
+			},
+			"a",
+		},
+		{
+			"let f = fn() { let = 1; 2 + ; 3 }; let g = 1;",
+			[]string{
+				"expected next token to be IDENT, got = instead",
+				"no prefix parse function found for ;",
+			},
+			"let g = 1;",
+		},
+		{
+			"if (a) { 1 + ; } let y = 2; y",
+			[]string{"no prefix parse function found for ;"},
+			"let y = 2;y",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("%q: expected %d errors, got=%d: %q",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for i, expected := range tt.expectedErrors {
+			if errors[i] != expected {
+				t.Errorf("%q: errors[%d]: expected=%q, got=%q",
+					tt.input, i, expected, errors[i])
+			}
+		}
+		// statements that failed to parse are left out
+		if program.String() != tt.expectedString {
+			t.Errorf("%q: program.String(): expected=%q, got=%q",
+				tt.input, tt.expectedString, program.String())
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []string{
+		"let x = 5;",
+		"let y = x * (2 + 3);",
+		"return x + 1;",
+		"-a * b; !ok",
+		"a + b; c - d; e",
+		"if (x < y) { x }",
+		"if (x) { x; y } else { z }",
+		"if (a) { 1 } else { if (b) { 2 } else { 3 } }",
+		"if (a) { 1 } else if (b) { 2 } else if (c) { 3 } else { 4 }",
+		"let add = fn(x, y) { x + y; };",
+		"fn() { }",
+		"fn(x) { return x; }(5)",
+		"fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }(5)",
+		"add(1, 2 * 3, add(4, 5))",
+		"[1, 2 * 2, \"three\", [4]]",
+		"arr[1 + 1]",
+		"{\"one\": 1 + 1}",
+		"{}",
+		"{\"key\": [1, 2]}[\"key\"][0]",
+		"\"hello ${name}\"",
+		"`say \"hi\"`",
+		"`raw ${name}`",
+		"let i = 0; i = i + 1; i++; i--",
+		"while (i < 10) { i++; if (i == 5) { break; } continue; }",
+		"do { i++ } while (i < 3)",
+		"for (let i = 0; i < 3; i++) { x = x + i }",
+		"for (;;) { break; }",
+		"switch (x) { case 1: \"one\"; case 2: a; b; default: \"other\" }",
+		"99999999999999999999 + 1",
+		"not x; not not y",
+		"try { f(1) } catch (e) { e }",
+		"a[0] = 1; h[\"k\"][i + 1] = [2]",
+		"(a = 1) + 2",
+		"-(a = 1)",
+		"(a[0] = 1) * 2; a = b = 3",
+		"1 < x <= 10; (a < b) < c",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		printed := program.String()
+
+		l = lexer.New(printed)
+		p = New(l)
+		reparsed := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Errorf("re-parsing %q failed: %q", printed, p.Errors())
+			continue
+		}
+		if len(reparsed.Statements) != len(program.Statements) {
+			t.Errorf("len(reparsed.Statements) for %q: expected=%d, got=%d",
+				printed, len(program.Statements), len(reparsed.Statements))
+			continue
+		}
+		if reparsed.String() != printed {
+			t.Errorf("round trip of %q: expected=%q, got=%q",
+				input, printed, reparsed.String())
+		}
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	input := `import "lib.monkey";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("len(program.Statements): expected=%d, got=%d",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ImportStatement, got=%T",
+			program.Statements[0])
+	}
+	if stmt.Path.Value != "lib.monkey" {
+		t.Errorf("stmt.Path.Value: expected=%q, got=%q", "lib.monkey", stmt.Path.Value)
+	}
+	if stmt.String() != input {
+		t.Errorf("stmt.String(): expected=%q, got=%q", input, stmt.String())
+	}
+}
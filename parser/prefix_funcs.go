@@ -1,10 +1,14 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/anukuljoshi/monkey/ast"
+	"github.com/anukuljoshi/monkey/lexer"
 	"github.com/anukuljoshi/monkey/token"
 )
 
@@ -19,6 +23,9 @@ func (p *Parser) parseIdentifer() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return p.parseBigIntegerLiteral()
+	}
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
 		p.errors = append(p.errors, msg)
@@ -28,6 +35,26 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseBigIntegerLiteral() ast.Expression {
+	value, ok := new(big.Int).SetString(p.curToken.Literal, 0)
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &ast.BigIntegerLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &ast.FloatLiteral{Token: p.curToken, Value: value}
+}
+
 // boolean
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{
@@ -38,12 +65,103 @@ func (p *Parser) parseBoolean() ast.Expression {
 
 // string
 func (p *Parser) parseStringLiteral() ast.Expression {
+	if strings.Contains(p.curToken.Literal, "${") {
+		return p.parseInterpolatedString()
+	}
 	return &ast.StringLiteral{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
 	}
 }
 
+// raw string, taken verbatim so ${...} is not interpolated
+func (p *Parser) parseRawStringLiteral() ast.Expression {
+	return &ast.StringLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+}
+
+// parseInterpolatedString splits a string literal into its plain text parts
+// and the expressions inside ${...}, each parsed by a parser of its own
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	literal := p.curToken.Literal
+
+	for len(literal) > 0 {
+		start := strings.Index(literal, "${")
+		if start < 0 {
+			str.Parts = append(str.Parts, p.stringPart(literal))
+			break
+		}
+		if start > 0 {
+			str.Parts = append(str.Parts, p.stringPart(literal[:start]))
+		}
+
+		end := matchingBrace(literal, start+2)
+		if end < 0 {
+			msg := fmt.Sprintf("unterminated ${ in string %q", p.curToken.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		exp := p.parseInterpolation(literal[start+2 : end])
+		if exp == nil {
+			return nil
+		}
+		str.Parts = append(str.Parts, exp)
+		literal = literal[end+1:]
+	}
+	return str
+}
+
+func (p *Parser) stringPart(value string) *ast.StringLiteral {
+	return &ast.StringLiteral{
+		Token: token.Token{Type: token.STRING, Literal: value},
+		Value: value,
+	}
+}
+
+func (p *Parser) parseInterpolation(source string) ast.Expression {
+	inner := New(lexer.New(source))
+	program := inner.ParseProgram()
+	if len(inner.Errors()) != 0 {
+		p.errors = append(p.errors, inner.Errors()...)
+		return nil
+	}
+
+	if len(program.Statements) != 1 {
+		msg := fmt.Sprintf("expected a single expression in ${%s}", source)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		msg := fmt.Sprintf("expected a single expression in ${%s}", source)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return stmt.Expression
+}
+
+// matchingBrace returns the index of the } closing a brace opened just
+// before from, or -1 when it is never closed
+func matchingBrace(s string, from int) int {
+	depth := 1
+	for i := from; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	var list []ast.Expression
 	if p.peekTokenIs(end) {
@@ -95,6 +213,73 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseComparisonExpression parses a < b, or a chain such as a < b <= c
+// when further comparisons follow without parentheses
+func (p *Parser) parseComparisonExpression(left ast.Expression) ast.Expression {
+	first := p.parseInfixExpression(left).(*ast.InfixExpression)
+	if !p.peekIsComparison() {
+		return first
+	}
+	chain := &ast.ChainedComparison{
+		Token:     first.Token,
+		Operands:  []ast.Expression{first.Left, first.Right},
+		Operators: []string{first.Operator},
+	}
+	for p.peekIsComparison() {
+		p.nextToken()
+		chain.Operators = append(chain.Operators, p.curToken.Literal)
+		p.nextToken()
+		chain.Operands = append(chain.Operands, p.parseExpression(LESSERGREATER))
+	}
+	return chain
+}
+
+func (p *Parser) peekIsComparison() bool {
+	switch p.peekToken.Type {
+	case token.LT, token.GT, token.LT_EQ, token.GT_EQ:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	ident, ok := left.(*ast.Identifier)
+	if !ok {
+		msg := fmt.Sprintf("cannot apply %s to %s", p.curToken.Literal, left.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &ast.PostfixExpression{
+		Token:    p.curToken,
+		Left:     ident,
+		Operator: p.curToken.Literal,
+	}
+}
+
+// assignment
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	if index, ok := left.(*ast.IndexExpression); ok {
+		exp := &ast.IndexAssignExpression{Token: p.curToken, Left: index}
+		p.nextToken()
+		exp.Value = p.parseExpression(ASSIGNMENT - 1)
+		return exp
+	}
+	ident, ok := left.(*ast.Identifier)
+	if !ok {
+		msg := fmt.Sprintf("cannot assign to %s", left.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	exp := &ast.AssignExpression{
+		Token: p.curToken,
+		Name:  ident,
+	}
+	p.nextToken()
+	// parse with lower precedence so that a = b = c groups to the right
+	exp.Value = p.parseExpression(ASSIGNMENT - 1)
+	return exp
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 
@@ -127,6 +312,21 @@ func (p *Parser) parseIfExpression() ast.Expression {
 
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			ifToken := p.curToken
+			nested := p.parseIfExpression()
+			if nested == nil {
+				return nil
+			}
+			exp.Alternative = &ast.BlockStatement{
+				Token: ifToken,
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{Token: ifToken, Expression: nested},
+				},
+			}
+			return exp
+		}
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -135,13 +335,71 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return exp
 }
 
-func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+// switch
+func (p *Parser) parseSwitchExpression() ast.Expression {
+	exp := &ast.SwitchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.nextToken()
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.CASE:
+			arm := &ast.SwitchCase{Token: p.curToken}
+			p.nextToken()
+			arm.Value = p.parseExpression(LOWEST)
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			arm.Body = p.parseCaseBody()
+			exp.Cases = append(exp.Cases, arm)
+		case token.DEFAULT:
+			if exp.Default != nil {
+				p.errors = append(p.errors, "multiple default cases in switch")
+				return nil
+			}
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			exp.Default = p.parseCaseBody()
+		default:
+			msg := fmt.Sprintf("expected case or default in switch, got %s",
+				p.curToken.Type)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+	}
+
+	if !p.curTokenIs(token.RBRACE) {
+		p.peekError(token.RBRACE)
+		return nil
+	}
+	return exp
+}
+
+// parseCaseBody reads statements after a case label up to the next label
+// or the closing brace, leaving curToken on that token
+func (p *Parser) parseCaseBody() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
 	p.nextToken()
 
-	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+	for !p.curTokenIs(token.CASE) && !p.curTokenIs(token.DEFAULT) &&
+		!p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
@@ -151,9 +409,152 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	return block
 }
 
+func (p *Parser) parseTryExpression() ast.Expression {
+	exp := &ast.TryExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+
+	if !p.expectPeek(token.CATCH) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Handler = p.parseBlockStatement()
+	return exp
+}
+
+// loops
+func (p *Parser) parseWhileExpression() ast.Expression {
+	exp := &ast.WhileExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	exp.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+	return exp
+}
+
+func (p *Parser) parseDoWhileExpression() ast.Expression {
+	exp := &ast.DoWhileExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+
+	if !p.expectPeek(token.WHILE) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	exp.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseForExpression() ast.Expression {
+	exp := &ast.ForExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	// init statement, statement parsers leave curToken on the ';'
+	p.nextToken()
+	if !p.curTokenIs(token.SEMICOLON) {
+		exp.Init = p.parseStatement()
+		if !p.curTokenIs(token.SEMICOLON) && !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	if !p.curTokenIs(token.SEMICOLON) {
+		exp.Condition = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	if !p.curTokenIs(token.RPAREN) {
+		exp.Post = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+	return exp
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		if stmt := p.parseStatementOrSkip(); stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	return block
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
 
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		lit.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
@@ -237,12 +638,12 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		key := p.parseExpression(LOWEST)
 		if !p.expectPeek(token.COLON) {
-			// TODO: return error
 			return nil
 		}
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
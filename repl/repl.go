@@ -14,16 +14,16 @@ import (
 const PROMPT = ">> "
 
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	// shared with the input builtin, so scripts read the lines after their own
+	reader := bufio.NewReader(in)
 	env := object.NewEnvironment()
 
 	for {
 		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
 			return
 		}
-		line := scanner.Text()
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -32,7 +32,17 @@ func Start(in io.Reader, out io.Writer) {
 			printParserErrors(out, p.Errors())
 			continue
 		}
-		evaluated := evaluator.Eval(program, env)
+		if len(program.Statements) == 0 {
+			continue
+		}
+		evaluated := evaluator.EvalWithOptions(program, env, evaluator.EvalOptions{
+			Output: out,
+			Input:  reader,
+		})
+		last := program.Statements[len(program.Statements)-1]
+		if evaluator.IsBinding(last) && !isError(evaluated) {
+			continue
+		}
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
@@ -40,6 +50,10 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	io.WriteString(out, "Whoops! We ran into some problem!\n")
 	io.WriteString(out, " parser errors:\n")
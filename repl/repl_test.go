@@ -0,0 +1,27 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStartSuppressesBindings(t *testing.T) {
+	in := strings.Join([]string{
+		"let x = 5;",
+		"x",
+		"x = x + 2",
+		"x",
+		"let a = [1, 2]; a[0] = x;",
+		"a",
+		"let y = missing;",
+		"print(x)",
+	}, "\n")
+	var out bytes.Buffer
+	Start(strings.NewReader(in), &out)
+
+	expected := "5\n7\n[7, 2]\nERROR: identifier not found: missing\n7\nnull\n"
+	if out.String() != expected {
+		t.Errorf("output: expected=%q, got=%q", expected, out.String())
+	}
+}
@@ -1,10 +1,14 @@
 package token
 
+import "sort"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // 1-based line of the first character
+	Column  int // 1-based column of the first character
 }
 
 // tokens
@@ -13,9 +17,11 @@ const (
 	EOF     = "EOF"
 
 	// Identifiers + literals
-	IDENT  = "IDENT"  // add, foobar, x, y, ...
-	INT    = "INT"    // 1343456
-	STRING = "STRING" // "hello world"
+	IDENT      = "IDENT"      // add, foobar, x, y, ...
+	INT        = "INT"        // 1343456
+	FLOAT      = "FLOAT"      // 3.14
+	STRING     = "STRING"     // "hello world"
+	RAW_STRING = "RAW_STRING" // `hello world`
 
 	// Operators
 	ASSIGN   = "="
@@ -25,12 +31,21 @@ const (
 	ASTERISK = "*"
 	FSLASH   = "/"
 
-	LT = "<"
-	GT = ">"
+	INC = "++"
+	DEC = "--"
+
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	// the keywords and, or lex to these too
+	AND = "&&"
+	OR  = "||"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
@@ -52,6 +67,24 @@ const (
 	RETURN = "RETURN"
 	TRUE   = "TRUE"
 	FALSE  = "FALSE"
+	WHILE  = "WHILE"
+	DO     = "DO"
+	FOR    = "FOR"
+
+	SWITCH  = "SWITCH"
+	CASE    = "CASE"
+	DEFAULT = "DEFAULT"
+
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+
+	IMPORT = "IMPORT"
+
+	TRY   = "TRY"
+	CATCH = "CATCH"
+
+	IN  = "IN"
+	NOT = "NOT"
 )
 
 var keywords = map[string]TokenType{
@@ -62,11 +95,43 @@ var keywords = map[string]TokenType{
 	"return": RETURN,
 	"true":   TRUE,
 	"false":  FALSE,
+	"while":  WHILE,
+	"do":     DO,
+	"for":    FOR,
+
+	"switch":  SWITCH,
+	"case":    CASE,
+	"default": DEFAULT,
+
+	"break":    BREAK,
+	"continue": CONTINUE,
+
+	"import": IMPORT,
+
+	"try":   TRY,
+	"catch": CATCH,
+
+	"in":  IN,
+	"not": NOT,
+	"and": AND,
+	"or":  OR,
 }
 
+// LookupIdent returns the token type of a keyword, or IDENT for any other
+// identifier.
 func LookupIdent(ident string) TokenType {
 	if tok, ok := keywords[ident]; ok {
 		return tok
 	}
 	return IDENT
 }
+
+// Keywords returns the reserved words of the language in alphabetical order.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
@@ -0,0 +1,46 @@
+package token
+
+import "testing"
+
+func TestLookupIdent(t *testing.T) {
+	tests := []struct {
+		ident    string
+		expected TokenType
+	}{
+		{"fn", FUNCTION},
+		{"let", LET},
+		{"while", WHILE},
+		{"catch", CATCH},
+		{"and", AND},
+		{"not", NOT},
+		{"foobar", IDENT},
+		{"Let", IDENT},
+		{"lets", IDENT},
+	}
+
+	for _, tt := range tests {
+		if got := LookupIdent(tt.ident); got != tt.expected {
+			t.Errorf("LookupIdent(%q): expected=%q, got=%q", tt.ident, tt.expected, got)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	words := Keywords()
+	if len(words) != len(keywords) {
+		t.Fatalf("wrong number of keywords. expected=%d, got=%d", len(keywords), len(words))
+	}
+	for i, word := range words {
+		if LookupIdent(word) == IDENT {
+			t.Errorf("%q is not a keyword", word)
+		}
+		if i > 0 && words[i-1] >= word {
+			t.Errorf("keywords not sorted: %q before %q", words[i-1], word)
+		}
+	}
+
+	words[0] = "changed"
+	if Keywords()[0] == "changed" {
+		t.Errorf("Keywords returned a shared slice")
+	}
+}